@@ -0,0 +1,66 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"mime"
+	"strings"
+)
+
+// CloudEvents as defined by the NATS protocol binding of the CloudEvents specification.
+const (
+	// CloudEventsSpecVersion is the version of the CloudEvents specification events are emitted with.
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsHeaderPrefix prefixes the names of the headers carrying event attributes in binary mode.
+	CloudEventsHeaderPrefix = "ce-"
+	// CloudEventsContentType is the content type of events in structured mode.
+	CloudEventsContentType = "application/cloudevents+json"
+	// CloudEventsMetadataPrefix prefixes the metadata keys carrying event attributes,
+	// e.g. "cloudevents.id" or "cloudevents.type".
+	CloudEventsMetadataPrefix = "cloudevents."
+)
+
+// Names of the CloudEvents context attributes the connector reads or derives.
+const (
+	CloudEventsAttrSpecVersion     = "specversion"
+	CloudEventsAttrID              = "id"
+	CloudEventsAttrSource          = "source"
+	CloudEventsAttrType            = "type"
+	CloudEventsAttrSubject         = "subject"
+	CloudEventsAttrTime            = "time"
+	CloudEventsAttrDataContentType = "datacontenttype"
+)
+
+// IsJSONContentType reports whether the content type is application/json or a JSON-based format.
+// An empty content type is JSON as well, as the CloudEvents specification assumes for event data.
+func IsJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// IsCloudEventsContentType reports whether the content type is the one of events in structured mode.
+func IsCloudEventsContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	return err == nil && mediaType == CloudEventsContentType
+}
@@ -0,0 +1,196 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	// ErrUnsupportedEncoding occurs when a payload is encoded with an unknown codec.
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	// ErrDecompressedTooLarge occurs when a decompressed payload exceeds the maximum size.
+	ErrDecompressedTooLarge = errors.New("decompressed payload exceeds the maximum size")
+)
+
+// Names of the supported compression codecs, used as Content-Encoding header values.
+const (
+	CompressionNone   = "none"
+	CompressionGzip   = "gzip"
+	CompressionZstd   = "zstd"
+	CompressionSnappy = "snappy"
+)
+
+// zstd encoders and decoders are expensive to create, but safe for concurrent use
+// of EncodeAll and DecodeAll, so they are shared. Decoders are shared per maximum size.
+var (
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+		return zstd.NewWriter(nil)
+	})
+	zstdDecoders sync.Map
+)
+
+// zstdDecoder returns the shared decoder limiting the decoded size to maxSize bytes, unless it's 0.
+func zstdDecoder(maxSize int) (*zstd.Decoder, error) {
+	if dec, ok := zstdDecoders.Load(maxSize); ok {
+		return dec.(*zstd.Decoder), nil //nolint:forcetypeassert // the map only holds decoders
+	}
+
+	var opts []zstd.DOption
+	if maxSize > 0 {
+		opts = append(opts, zstd.WithDecoderMaxMemory(uint64(maxSize)))
+	}
+
+	dec, err := zstd.NewReader(nil, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create zstd decoder: %w", err)
+	}
+
+	actual, loaded := zstdDecoders.LoadOrStore(maxSize, dec)
+	if loaded {
+		dec.Close()
+	}
+
+	return actual.(*zstd.Decoder), nil //nolint:forcetypeassert // the map only holds decoders
+}
+
+// IsCompressionCodec reports whether the codec is one of the codecs payloads are compressed with.
+func IsCompressionCodec(codec string) bool {
+	switch codec {
+	case CompressionGzip, CompressionZstd, CompressionSnappy:
+		return true
+	default:
+		return false
+	}
+}
+
+// Compress compresses the data with the given codec.
+func Compress(codec string, data []byte) ([]byte, error) {
+	switch codec {
+	case CompressionGzip:
+		var b bytes.Buffer
+
+		w := gzip.NewWriter(&b)
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("gzip write: %w", err)
+		}
+
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("gzip close: %w", err)
+		}
+
+		return b.Bytes(), nil
+
+	case CompressionZstd:
+		enc, err := zstdEncoder()
+		if err != nil {
+			return nil, fmt.Errorf("create zstd encoder: %w", err)
+		}
+
+		return enc.EncodeAll(data, nil), nil
+
+	case CompressionSnappy:
+		return snappy.Encode(nil, data), nil
+
+	case CompressionNone, "":
+		return data, nil
+
+	default:
+		return nil, fmt.Errorf("%q: %w", codec, ErrUnsupportedEncoding)
+	}
+}
+
+// Decompress decompresses the data compressed with the given codec. It fails with
+// ErrDecompressedTooLarge if the decompressed data exceeds maxSize bytes, unless it's 0.
+func Decompress(codec string, data []byte, maxSize int) ([]byte, error) {
+	switch codec {
+	case CompressionGzip:
+		return gunzip(data, maxSize)
+
+	case CompressionZstd:
+		dec, err := zstdDecoder(maxSize)
+		if err != nil {
+			return nil, err
+		}
+
+		decoded, err := dec.DecodeAll(data, nil)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+			return nil, fmt.Errorf("zstd decode: %w", ErrDecompressedTooLarge)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("zstd decode: %w", err)
+		}
+
+		return decoded, nil
+
+	case CompressionSnappy:
+		size, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, fmt.Errorf("snappy decode: %w", err)
+		}
+
+		if maxSize > 0 && size > maxSize {
+			return nil, fmt.Errorf("snappy decode: %w", ErrDecompressedTooLarge)
+		}
+
+		decoded, err := snappy.Decode(nil, data)
+		if err != nil {
+			return nil, fmt.Errorf("snappy decode: %w", err)
+		}
+
+		return decoded, nil
+
+	case CompressionNone, "":
+		return data, nil
+
+	default:
+		return nil, fmt.Errorf("%q: %w", codec, ErrUnsupportedEncoding)
+	}
+}
+
+// gunzip decompresses the gzip data, reading no more than maxSize bytes, unless it's 0.
+func gunzip(data []byte, maxSize int) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("create gzip reader: %w", err)
+	}
+	defer r.Close()
+
+	var src io.Reader = r
+	if maxSize > 0 {
+		// read one byte more to tell a payload of exactly maxSize bytes from a larger one
+		src = io.LimitReader(r, int64(maxSize)+1)
+	}
+
+	decoded, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("gzip read: %w", err)
+	}
+
+	if maxSize > 0 && len(decoded) > maxSize {
+		return nil, fmt.Errorf("gzip read: %w", ErrDecompressedTooLarge)
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,161 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+var codecs = []string{CompressionGzip, CompressionZstd, CompressionSnappy}
+
+// testPayload returns a JSON payload of roughly the given size.
+func testPayload(size int) []byte {
+	var b bytes.Buffer
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, `{"id":%d,"name":"user-%d","email":"user-%d@example.com","active":true}`, i, i, i)
+	}
+
+	return b.Bytes()
+}
+
+func TestCompress_RoundTrip(t *testing.T) {
+	payload := testPayload(64 << 10)
+
+	for _, codec := range append(codecs, CompressionNone) {
+		t.Run(codec, func(t *testing.T) {
+			is := is.New(t)
+
+			compressed, err := Compress(codec, payload)
+			is.NoErr(err)
+
+			if codec != CompressionNone {
+				is.True(len(compressed) < len(payload))
+			}
+
+			// a payload of exactly the maximum size is decompressed
+			decompressed, err := Decompress(codec, compressed, len(payload))
+			is.NoErr(err)
+			is.Equal(decompressed, payload)
+		})
+	}
+}
+
+func TestCompress_UnsupportedEncoding(t *testing.T) {
+	is := is.New(t)
+
+	_, err := Compress("br", []byte("foo"))
+	is.True(errors.Is(err, ErrUnsupportedEncoding))
+
+	_, err = Decompress("br", []byte("foo"), 0)
+	is.True(errors.Is(err, ErrUnsupportedEncoding))
+}
+
+func TestDecompress_CorruptedData(t *testing.T) {
+	for _, codec := range codecs {
+		t.Run(codec, func(t *testing.T) {
+			is := is.New(t)
+
+			_, err := Decompress(codec, []byte("definitely not compressed"), 0)
+			is.True(err != nil)
+		})
+	}
+}
+
+func TestDecompress_MaxSize(t *testing.T) {
+	// a highly compressible payload, expanding to much more than it takes compressed
+	payload := make([]byte, 1<<20)
+
+	for _, codec := range codecs {
+		t.Run(codec, func(t *testing.T) {
+			is := is.New(t)
+
+			compressed, err := Compress(codec, payload)
+			is.NoErr(err)
+
+			_, err = Decompress(codec, compressed, len(payload)-1)
+			is.True(errors.Is(err, ErrDecompressedTooLarge))
+
+			// zero means no limit
+			decompressed, err := Decompress(codec, compressed, 0)
+			is.NoErr(err)
+			is.Equal(len(decompressed), len(payload))
+		})
+	}
+}
+
+func TestIsCompressionCodec(t *testing.T) {
+	is := is.New(t)
+
+	for _, codec := range codecs {
+		is.True(IsCompressionCodec(codec))
+	}
+
+	for _, encoding := range []string{"", CompressionNone, "identity", "br", "deflate"} {
+		is.True(!IsCompressionCodec(encoding))
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	for _, size := range []int{1 << 10, 64 << 10, 1 << 20} {
+		payload := testPayload(size)
+
+		for _, codec := range codecs {
+			b.Run(fmt.Sprintf("%s/%dKiB", codec, size>>10), func(b *testing.B) {
+				b.SetBytes(int64(len(payload)))
+				b.ReportAllocs()
+
+				var compressed []byte
+				for i := 0; i < b.N; i++ {
+					var err error
+					compressed, err = Compress(codec, payload)
+					if err != nil {
+						b.Fatalf("compress: %v", err)
+					}
+				}
+
+				b.ReportMetric(float64(len(compressed))/float64(len(payload)), "ratio")
+			})
+		}
+	}
+}
+
+func BenchmarkDecompress(b *testing.B) {
+	for _, size := range []int{1 << 10, 64 << 10, 1 << 20} {
+		payload := testPayload(size)
+
+		for _, codec := range codecs {
+			compressed, err := Compress(codec, payload)
+			if err != nil {
+				b.Fatalf("compress: %v", err)
+			}
+
+			b.Run(fmt.Sprintf("%s/%dKiB", codec, size>>10), func(b *testing.B) {
+				b.SetBytes(int64(len(payload)))
+				b.ReportAllocs()
+
+				for i := 0; i < b.N; i++ {
+					if _, err := Decompress(codec, compressed, 0); err != nil {
+						b.Fatalf("decompress: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -15,11 +15,34 @@
 package common
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// EnvReferencePrefix marks a config value as a reference to an environment variable,
+// e.g. "env:NATS_SEED" is resolved to the value of the NATS_SEED environment variable.
+const EnvReferencePrefix = "env:"
+
+// redactedPlaceholder replaces secrets in logged values.
+const redactedPlaceholder = "xxxxx"
+
+var (
+	// ErrMultipleAuthMethods occurs when more than one authentication method is configured.
+	ErrMultipleAuthMethods = errors.New("only one of username, token, nkeyPath, credentialsFilePath, nkeySeed or jwt can be set")
+	// ErrPasswordWithoutUsername occurs when a password is configured without a username.
+	ErrPasswordWithoutUsername = errors.New("username is required when password is set")
+	// ErrJWTWithoutSeed occurs when a user JWT is configured without the NKey seed used to sign the server nonce.
+	ErrJWTWithoutSeed = errors.New("nkeySeed is required when jwt is set")
+	// ErrEnvNotSet occurs when a config value references an environment variable that isn't set.
+	ErrEnvNotSet = errors.New("environment variable is not set")
+)
+
 // Config contains configurable values
 // shared between source and destination NATS PubSub connector.
 type Config struct {
@@ -29,18 +52,76 @@ type Config struct {
 	Subject string `json:"subject" validate:"required"`
 	// Optional connection name (can come in handy when it comes to monitoring).
 	ConnectionName string `json:"connectionName"`
+	// A username for username/password authentication, used instead of embedding
+	// the credentials in the URLs.
+	Username string `json:"username"`
+	// A password for username/password authentication.
+	// Use "env:NAME" to read it from the environment variable NAME.
+	Password string `json:"password"`
+	// A token for token authentication, used instead of embedding the token in the URLs.
+	// Use "env:NAME" to read it from the environment variable NAME.
+	Token string `json:"token"`
 	// A path pointed to a NKey pair.
 	NKeyPath string `json:"nkeyPath"`
 	// A path pointed to a credentials file.
 	CredentialsFilePath string `json:"credentialsFilePath"`
+	// An NKey seed, used instead of nkeyPath, or together with jwt instead of
+	// credentialsFilePath. Use "env:NAME" to read it from the environment variable NAME.
+	NKeySeed string `json:"nkeySeed"`
+	// A user JWT, used together with nkeySeed instead of credentialsFilePath.
+	// Use "env:NAME" to read it from the environment variable NAME.
+	JWT string `json:"jwt"`
 	// Sets the number of reconnect attempts that will be tried before giving up.
 	// If negative, it will never give up trying to reconnect.
 	MaxReconnects int `json:"maxReconnects" default:"5"`
 	// Sets the time to backoff after attempting a reconnect to a server that we
 	// were already connected to previously, formatted as a time.Duration string.
 	ReconnectWait time.Duration `json:"reconnectWait" default:"5s"`
+	// The maximum random time added to reconnectWait for plain connections,
+	// so clients don't reconnect all at once.
+	ReconnectJitter time.Duration `json:"reconnectJitter" default:"100ms"`
+	// The maximum random time added to reconnectWait for TLS connections.
+	ReconnectJitterTLS time.Duration `json:"reconnectJitterTLS" default:"1s"`
+	// The size in bytes of the buffer holding outgoing messages while reconnecting.
+	// Set it to -1 to disable buffering, publishing then fails while disconnected.
+	ReconnectBufferSize int `json:"reconnectBufferSize" default:"8388608" validate:"gt=-2"`
+	// The maximum time to establish a connection to a server.
+	ConnectTimeout time.Duration `json:"connectTimeout" default:"2s"`
+	// The interval of pings sent to the server to check the connection.
+	PingInterval time.Duration `json:"pingInterval" default:"2m"`
+	// The number of pings without a reply before the connection is considered stale.
+	MaxPingsOutstanding int `json:"maxPingsOutstanding" default:"2" validate:"gt=0"`
+	// Keep trying to connect in the background if no server can be reached initially,
+	// instead of failing right away.
+	RetryOnFailedConnect bool `json:"retryOnFailedConnect"`
+	// The maximum time to wait for the initial connection when retryOnFailedConnect
+	// is set. If 0, the connector starts right away and connects in the background.
+	StartupWait time.Duration `json:"startupWait" default:"30s"`
+	// Share one connection with the other connectors in this process that have
+	// identical connection settings, instead of opening a connection per connector.
+	SharedConnection bool `json:"sharedConnection"`
+	// Connect to the servers in the order of the URLs, instead of a random order.
+	DontRandomize bool `json:"dontRandomize"`
+	// Don't deliver messages published by this connection to its own subscriptions.
+	NoEcho bool `json:"noEcho"`
+	// The prefix of the subjects used for replies, instead of "_INBOX".
+	InboxPrefix string `json:"inboxPrefix"`
+	// The maximum time to write buffered messages to the server before the
+	// connection is considered stale.
+	FlusherTimeout time.Duration `json:"flusherTimeout" default:"1m"`
 
-	TLS TLSConfig `json:"tls"`
+	TLS       TLSConfig       `json:"tls"`
+	WebSocket WebSocketConfig `json:"websocket"`
+	Proxy     ProxyConfig     `json:"proxy"`
+}
+
+// WebSocketConfig contains the settings of connections to ws:// and wss:// URLs.
+type WebSocketConfig struct {
+	// A path added to the URLs, for servers behind a proxy that routes
+	// WebSocket connections by path, e.g. "/nats".
+	ProxyPath string `json:"proxyPath"`
+	// Enables per-message compression of WebSocket frames, if the server supports it.
+	Compression bool `json:"compression"`
 }
 
 type TLSConfig struct {
@@ -53,12 +134,84 @@ type TLSConfig struct {
 	// A path pointed to a TLS root certificate, provide it if you want to verify
 	// the server's identity.
 	RootCACertPath string `json:"rootCACertPath"`
+	// A PEM-encoded TLS client certificate, used instead of tls.clientCertPath.
+	// Use "env:NAME" to read it from the environment variable NAME.
+	ClientCert string `json:"clientCert"`
+	// A PEM-encoded TLS client private key, used instead of tls.clientPrivateKeyPath.
+	// Use "env:NAME" to read it from the environment variable NAME.
+	ClientPrivateKey string `json:"clientPrivateKey"`
+	// A PEM-encoded TLS root certificate, used instead of tls.rootCACertPath.
+	// Use "env:NAME" to read it from the environment variable NAME.
+	RootCACert string `json:"rootCACert"`
+	// The server name used to verify the server's certificate, overrides the host of the URLs.
+	ServerName string `json:"serverName"`
+	// The minimum TLS version to accept.
+	MinVersion string `json:"minVersion" default:"1.2" validate:"inclusion=1.2|1.3"`
+	// A comma-separated list of allowed cipher suites, e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256.
+	// Applies to TLS 1.2 only, TLS 1.3 cipher suites aren't configurable. Defaults to Go's secure defaults.
+	CipherSuites []string `json:"cipherSuites"`
+	// Skip verifying the server's certificate. Only use it with development clusters.
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+	// Perform the TLS handshake before the server sends its INFO message, required
+	// for servers behind TLS-terminating proxies.
+	HandshakeFirst bool `json:"handshakeFirst"`
+}
+
+// Validate checks the config values that can't be expressed as parameter validations.
+func (c Config) Validate() error {
+	if c.Password != "" && c.Username == "" {
+		return ErrPasswordWithoutUsername
+	}
+
+	if c.JWT != "" && c.NKeySeed == "" {
+		return ErrJWTWithoutSeed
+	}
+
+	if c.authMethods() > 1 {
+		return ErrMultipleAuthMethods
+	}
+
+	if err := c.validateTuning(); err != nil {
+		return err
+	}
+
+	if err := c.validateURLs(); err != nil {
+		return fmt.Errorf("validate urls: %w", err)
+	}
+
+	if err := ValidateSubject(c.Subject); err != nil {
+		return fmt.Errorf("validate subject: %w", err)
+	}
+
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("validate tls: %w", err)
+	}
+
+	if err := c.Proxy.Validate(); err != nil {
+		return fmt.Errorf("validate proxy: %w", err)
+	}
+
+	return nil
 }
 
+// GetConnectionName returns the connection name, and generates one if it's empty.
+// Shared connections get a name derived from their connection settings, so
+// connectors with identical settings in the same process keep sharing the connection.
 func (c *Config) GetConnectionName() string {
-	if c.ConnectionName == "" {
-		c.ConnectionName = GenerateConnectionName()
+	if c.ConnectionName != "" {
+		return c.ConnectionName
 	}
+
+	if c.SharedConnection {
+		if name, err := c.sharedConnectionName(); err == nil {
+			c.ConnectionName = name
+
+			return c.ConnectionName
+		}
+	}
+
+	c.ConnectionName = GenerateConnectionName()
+
 	return c.ConnectionName
 }
 
@@ -72,3 +225,62 @@ const (
 func GenerateConnectionName() string {
 	return DefaultConnectionNamePrefix + uuid.New().String()
 }
+
+// RedactedURLs returns the connection URLs with embedded credentials redacted, safe to be logged.
+func (c Config) RedactedURLs() []string {
+	urls := make([]string, len(c.URLs))
+	for i, u := range c.URLs {
+		urls[i] = RedactURL(normalizeURL(u))
+	}
+
+	return urls
+}
+
+// normalizeURL prefixes a connection URL without a scheme with "nats://",
+// as the NATS client does when it connects.
+func normalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		return "nats://" + rawURL
+	}
+
+	return rawURL
+}
+
+// RedactURL replaces the credentials embedded in a URL with a placeholder. The username
+// of a username/password pair is kept, a lone username is redacted, because NATS treats
+// it as a token.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// don't risk logging credentials of a URL that can't be parsed
+		return redactedPlaceholder
+	}
+
+	if u.User == nil {
+		return u.String()
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redactedPlaceholder)
+	} else {
+		u.User = url.User(redactedPlaceholder)
+	}
+
+	return u.String()
+}
+
+// resolveValue returns the value, or the value of the environment variable it references.
+func resolveValue(value string) (string, error) {
+	name, ok := strings.CutPrefix(value, EnvReferencePrefix)
+	if !ok {
+		return value, nil
+	}
+
+	resolved, ok := os.LookupEnv(name)
+	if !ok || resolved == "" {
+		return "", fmt.Errorf("%q: %w", name, ErrEnvNotSet)
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,185 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	// ErrStartupWaitExceeded occurs when the initial connection isn't established within startupWait.
+	ErrStartupWaitExceeded = errors.New("not connected within startupWait")
+	// ErrConnectRetriesExhausted occurs when the connection is closed before the initial connection is established.
+	ErrConnectRetriesExhausted = errors.New("gave up connecting after maxReconnects attempts")
+)
+
+// Conn is a connection to NATS returned by Config.Connect. If sharedConnection
+// is set, the underlying connection is shared with other connectors that have
+// identical connection settings.
+type Conn struct {
+	*nats.Conn
+
+	lease *lease
+}
+
+// SetErrorHandler sets the handler of asynchronous errors. Handlers of a shared
+// connection receive the errors of all subscriptions on the connection, except
+// permission violations, which reach only the handlers of the connectors
+// subscribed to the denied subject.
+func (c *Conn) SetErrorHandler(cb nats.ErrHandler) {
+	if c.lease == nil {
+		c.Conn.SetErrorHandler(cb)
+
+		return
+	}
+
+	c.lease.setErrorHandler(cb)
+}
+
+// ChanSubscribe subscribes to the subject, delivering the messages to the channel.
+// Subscriptions of a shared connection are recorded, so that permission violations
+// are reported only to the connector subscribed to the denied subject.
+func (c *Conn) ChanSubscribe(subject string, ch chan *nats.Msg) (*nats.Subscription, error) {
+	sub, err := c.Conn.ChanSubscribe(subject, ch)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to %s: %w", subject, err)
+	}
+
+	if c.lease != nil {
+		c.lease.addSubscription(sub)
+	}
+
+	return sub, nil
+}
+
+// Close closes the connection. A shared connection is closed only when the
+// last connector using it closes it, so its subscriptions must be unsubscribed first.
+func (c *Conn) Close() {
+	if c.lease == nil {
+		c.Conn.Close()
+
+		return
+	}
+
+	c.lease.release()
+}
+
+// connState reports when a connection is established or closed.
+type connState struct {
+	connected chan struct{}
+	closed    chan struct{}
+}
+
+// Connect connects to the configured servers with the connection options and the
+// extra options. If retryOnFailedConnect is set and no server can be reached, the
+// connection keeps connecting in the background and Connect waits up to startupWait
+// for it to be established. With a zero startupWait Connect returns right away, and
+// the returned connection might not be connected yet.
+//
+// If sharedConnection is set, only the handlers of the extra options are used.
+func (c Config) Connect(ctx context.Context, extraOpts ...nats.Option) (*Conn, error) {
+	if c.SharedConnection {
+		return sharedConns.acquire(ctx, c, extraOpts)
+	}
+
+	conn, state, err := c.dial(extraOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.waitConnected(ctx, conn, state); err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	return &Conn{Conn: conn}, nil
+}
+
+// dial connects to the configured servers with the connection options and the extra options.
+func (c Config) dial(extraOpts ...nats.Option) (*nats.Conn, *connState, error) {
+	opts, err := c.ConnectionOptions()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get connection options: %w", err)
+	}
+
+	state := &connState{connected: make(chan struct{}), closed: make(chan struct{})}
+
+	opts = append(opts, extraOpts...)
+	opts = append(opts, chainHandlers(
+		func(*nats.Conn) { close(state.connected) },
+		func(*nats.Conn) { close(state.closed) },
+	), countReconnects())
+
+	conn, err := nats.Connect(strings.Join(c.URLs, ","), opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dial %s: %w", strings.Join(c.RedactedURLs(), ","), err)
+	}
+
+	return conn, state, nil
+}
+
+// waitConnected waits up to startupWait for the connection to be established.
+func (c Config) waitConnected(ctx context.Context, conn *nats.Conn, state *connState) error {
+	if conn.IsConnected() || c.StartupWait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(c.StartupWait)
+	defer timer.Stop()
+
+	select {
+	case <-state.connected:
+		return nil
+	case <-state.closed:
+		return ErrConnectRetriesExhausted
+	case <-timer.C:
+		return ErrStartupWaitExceeded
+	case <-ctx.Done():
+		return fmt.Errorf("wait for connection: %w", ctx.Err())
+	}
+}
+
+// chainHandlers returns an option calling the connected and closed handlers
+// before the handlers set by the preceding options.
+func chainHandlers(connected, closed nats.ConnHandler) nats.Option {
+	return func(o *nats.Options) error {
+		prevConnected, prevClosed := o.ConnectedCB, o.ClosedCB
+
+		o.ConnectedCB = func(conn *nats.Conn) {
+			connected(conn)
+
+			if prevConnected != nil {
+				prevConnected(conn)
+			}
+		}
+
+		o.ClosedCB = func(conn *nats.Conn) {
+			closed(conn)
+
+			if prevClosed != nil {
+				prevClosed(conn)
+			}
+		}
+
+		return nil
+	}
+}
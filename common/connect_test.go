@@ -0,0 +1,150 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+func TestConfig_Connect_RetryOnFailedConnect(t *testing.T) {
+	is := is.New(t)
+
+	port := freePort(t)
+
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, NoLog: true, NoSigs: true})
+	is.NoErr(err)
+
+	t.Cleanup(srv.Shutdown)
+
+	// the server comes up only after the connector started connecting
+	time.AfterFunc(300*time.Millisecond, srv.Start)
+
+	// handlers passed by the caller are kept
+	connected := make(chan struct{})
+
+	conn, err := Config{
+		URLs:                 []string{fmt.Sprintf("nats://127.0.0.1:%d", port)},
+		MaxReconnects:        -1,
+		ReconnectWait:        50 * time.Millisecond,
+		RetryOnFailedConnect: true,
+		StartupWait:          10 * time.Second,
+	}.Connect(context.Background(), nats.ConnectHandler(func(*nats.Conn) { close(connected) }))
+	is.NoErr(err)
+
+	defer conn.Close()
+
+	is.True(conn.IsConnected())
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connect handler wasn't called")
+	}
+}
+
+func TestConfig_Connect_Unreachable(t *testing.T) {
+	url := fmt.Sprintf("nats://127.0.0.1:%d", freePort(t))
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr error
+	}{
+		{
+			name: "no retry",
+			cfg:  Config{URLs: []string{url}},
+		},
+		{
+			name: "startup wait exceeded",
+			cfg: Config{
+				URLs:                 []string{url},
+				MaxReconnects:        -1,
+				ReconnectWait:        50 * time.Millisecond,
+				RetryOnFailedConnect: true,
+				StartupWait:          200 * time.Millisecond,
+			},
+			wantErr: ErrStartupWaitExceeded,
+		},
+		{
+			name: "retries exhausted",
+			cfg: Config{
+				URLs:                 []string{url},
+				MaxReconnects:        1,
+				ReconnectWait:        10 * time.Millisecond,
+				RetryOnFailedConnect: true,
+				StartupWait:          10 * time.Second,
+			},
+			wantErr: ErrConnectRetriesExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			_, err := tt.cfg.Connect(context.Background())
+			is.True(err != nil)
+
+			if tt.wantErr != nil {
+				is.True(errors.Is(err, tt.wantErr))
+			}
+		})
+	}
+}
+
+func TestConfig_Connect_NoStartupWait(t *testing.T) {
+	is := is.New(t)
+
+	start := time.Now()
+
+	conn, err := Config{
+		URLs:                 []string{fmt.Sprintf("nats://127.0.0.1:%d", freePort(t))},
+		MaxReconnects:        -1,
+		ReconnectWait:        time.Second,
+		RetryOnFailedConnect: true,
+	}.Connect(context.Background())
+	is.NoErr(err)
+
+	defer conn.Close()
+
+	// the connection keeps connecting in the background
+	is.True(!conn.IsConnected())
+	is.True(!conn.IsClosed())
+	is.True(time.Since(start) < time.Second)
+}
+
+func TestConfig_Connect_ContextCanceled(t *testing.T) {
+	is := is.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := Config{
+		URLs:                 []string{fmt.Sprintf("nats://127.0.0.1:%d", freePort(t))},
+		MaxReconnects:        -1,
+		ReconnectWait:        50 * time.Millisecond,
+		RetryOnFailedConnect: true,
+		StartupWait:          10 * time.Second,
+	}.Connect(ctx)
+	is.True(errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,271 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrInvalidKeyID occurs when a key id contains characters other than letters, digits, '-' and '_'.
+	ErrInvalidKeyID = errors.New("key id must contain only letters, digits, '-' and '_'")
+	// ErrKeyNotFound occurs when a key provider has no key with the requested id.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrInvalidKey occurs when a key isn't a base64-encoded 16, 24 or 32 byte AES key.
+	ErrInvalidKey = errors.New("key must be a base64-encoded 16, 24 or 32 byte AES key")
+	// ErrKeyDirRequired occurs when the file key provider is configured without a key directory.
+	ErrKeyDirRequired = errors.New(`encryption.keyDir is required when encryption.keyProvider is "file"`)
+	// ErrInvalidCiphertext occurs when an encrypted payload is too short to contain a nonce.
+	ErrInvalidCiphertext = errors.New("ciphertext is too short")
+)
+
+// Names of the supported encryption key providers.
+const (
+	KeyProviderNone = "none"
+	KeyProviderFile = "file"
+	KeyProviderEnv  = "env"
+)
+
+// keyIDRegex restricts key ids, they come from message headers and are used in file paths.
+var keyIDRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// EncryptionConfig holds the configuration of loading the keys used to
+// envelope-encrypt message payloads.
+type EncryptionConfig struct {
+	// Where the encryption keys are loaded from. "none" disables encryption,
+	// "file" loads keys from files in encryption.keyDir named after the key ids,
+	// "env" loads keys from environment variables named encryption.keyEnvPrefix
+	// followed by the key id. Keys must be base64-encoded 16, 24 or 32 byte AES keys.
+	KeyProvider string `json:"keyProvider" default:"none" validate:"inclusion=none|file|env"`
+	// A path to a directory containing the key files.
+	KeyDir string `json:"keyDir"`
+	// A prefix of the environment variables containing the keys.
+	KeyEnvPrefix string `json:"keyEnvPrefix" default:"NATS_PUBSUB_KEY_"`
+}
+
+// Validate checks the encryption config.
+func (c EncryptionConfig) Validate() error {
+	if c.KeyProvider == KeyProviderFile && c.KeyDir == "" {
+		return ErrKeyDirRequired
+	}
+
+	return nil
+}
+
+// NewKeyProvider returns a key provider based on the config, or nil if encryption is disabled.
+func (c EncryptionConfig) NewKeyProvider() KeyProvider {
+	switch c.KeyProvider {
+	case KeyProviderFile:
+		return &FileKeyProvider{Dir: c.KeyDir}
+	case KeyProviderEnv:
+		return &EnvKeyProvider{Prefix: c.KeyEnvPrefix}
+	default:
+		return nil
+	}
+}
+
+// KeyProvider provides key encryption keys by their ids.
+// Keys of ids that weren't used before are looked up when they're first requested,
+// so rotated keys can be added under new ids without a restart, while old keys
+// remain available to decrypt messages encrypted with them.
+type KeyProvider interface {
+	Key(id string) ([]byte, error)
+}
+
+// FileKeyProvider loads keys from files in a directory, named after the key ids.
+// Keys are cached by id once loaded, so a key is rotated by adding a file with a
+// new id, not by replacing the contents of an existing file.
+type FileKeyProvider struct {
+	Dir string
+
+	cache sync.Map
+}
+
+// Key returns the key with the given id.
+func (p *FileKeyProvider) Key(id string) ([]byte, error) {
+	if !keyIDRegex.MatchString(id) {
+		return nil, fmt.Errorf("%q: %w", id, ErrInvalidKeyID)
+	}
+
+	if key, ok := p.cache.Load(id); ok {
+		return key.([]byte), nil //nolint:forcetypeassert // the cache contains only keys
+	}
+
+	raw, err := os.ReadFile(filepath.Join(p.Dir, id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%q: %w", id, ErrKeyNotFound)
+		}
+
+		return nil, fmt.Errorf("read key %q: %w", id, err)
+	}
+
+	key, err := decodeKey(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	p.cache.Store(id, key)
+
+	return key, nil
+}
+
+// EnvKeyProvider loads keys from environment variables named the prefix followed by the key ids.
+type EnvKeyProvider struct {
+	Prefix string
+}
+
+// Key returns the key with the given id.
+func (p *EnvKeyProvider) Key(id string) ([]byte, error) {
+	if !keyIDRegex.MatchString(id) {
+		return nil, fmt.Errorf("%q: %w", id, ErrInvalidKeyID)
+	}
+
+	raw, ok := os.LookupEnv(p.Prefix + id)
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", id, ErrKeyNotFound)
+	}
+
+	key, err := decodeKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	return key, nil
+}
+
+// decodeKey decodes a base64-encoded AES key.
+func decodeKey(raw string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, ErrInvalidKey
+	}
+}
+
+// Encrypt envelope-encrypts the data. It generates a random data key, encrypts the data
+// with it using AES-GCM, and encrypts the data key with the key identified by keyID.
+// It returns the encrypted data and the encrypted data key.
+func Encrypt(provider KeyProvider, keyID string, data []byte) ([]byte, []byte, error) {
+	key, err := provider.Key(keyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get key: %w", err)
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, fmt.Errorf("generate data key: %w", err)
+	}
+
+	ciphertext, err := seal(dataKey, data, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encrypt data: %w", err)
+	}
+
+	// bind the encrypted data key to the key id
+	wrappedKey, err := seal(key, dataKey, []byte(keyID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("encrypt data key: %w", err)
+	}
+
+	return ciphertext, wrappedKey, nil
+}
+
+// Decrypt decrypts the data encrypted by Encrypt.
+func Decrypt(provider KeyProvider, keyID string, ciphertext, wrappedKey []byte) ([]byte, error) {
+	key, err := provider.Key(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("get key: %w", err)
+	}
+
+	dataKey, err := open(key, wrappedKey, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt data key: %w", err)
+	}
+
+	data, err := open(dataKey, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt data: %w", err)
+	}
+
+	return data, nil
+}
+
+// seal encrypts the plaintext with AES-GCM and prepends the random nonce to the result.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the ciphertext produced by seal.
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// newGCM creates an AES-GCM cipher with the key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+
+	return aead, nil
+}
@@ -0,0 +1,242 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// writeKey writes a base64-encoded key of the given size to dir and returns the raw key.
+func writeKey(t *testing.T, dir, id string, size int) []byte {
+	t.Helper()
+
+	key := bytes.Repeat([]byte{byte(len(id))}, size)
+	err := os.WriteFile(filepath.Join(dir, id), []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600)
+	if err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	return key
+}
+
+func TestEncrypt_RoundTrip(t *testing.T) {
+	is := is.New(t)
+
+	dir := t.TempDir()
+	writeKey(t, dir, "k1", 32)
+
+	provider := &FileKeyProvider{Dir: dir}
+	payload := testPayload(4 << 10)
+
+	ciphertext, wrappedKey, err := Encrypt(provider, "k1", payload)
+	is.NoErr(err)
+	is.True(!bytes.Contains(ciphertext, payload[:64]))
+
+	got, err := Decrypt(provider, "k1", ciphertext, wrappedKey)
+	is.NoErr(err)
+	is.Equal(got, payload)
+}
+
+func TestEncrypt_EmptyPayload(t *testing.T) {
+	is := is.New(t)
+
+	dir := t.TempDir()
+	writeKey(t, dir, "k1", 16)
+
+	provider := &FileKeyProvider{Dir: dir}
+
+	ciphertext, wrappedKey, err := Encrypt(provider, "k1", nil)
+	is.NoErr(err)
+
+	got, err := Decrypt(provider, "k1", ciphertext, wrappedKey)
+	is.NoErr(err)
+	is.Equal(len(got), 0)
+}
+
+func TestDecrypt_KeyRotation(t *testing.T) {
+	is := is.New(t)
+
+	dir := t.TempDir()
+	writeKey(t, dir, "k1", 32)
+
+	provider := &FileKeyProvider{Dir: dir}
+
+	oldCiphertext, oldWrappedKey, err := Encrypt(provider, "k1", []byte("old"))
+	is.NoErr(err)
+
+	// rotate to a new key, the old one stays available
+	writeKey(t, dir, "k2", 24)
+
+	newCiphertext, newWrappedKey, err := Encrypt(provider, "k2", []byte("new"))
+	is.NoErr(err)
+
+	got, err := Decrypt(provider, "k1", oldCiphertext, oldWrappedKey)
+	is.NoErr(err)
+	is.Equal(got, []byte("old"))
+
+	got, err = Decrypt(provider, "k2", newCiphertext, newWrappedKey)
+	is.NoErr(err)
+	is.Equal(got, []byte("new"))
+
+	// the data key is bound to the key id
+	_, err = Decrypt(provider, "k2", oldCiphertext, oldWrappedKey)
+	is.True(err != nil)
+}
+
+func TestDecrypt_Tampered(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "k1", 32)
+
+	provider := &FileKeyProvider{Dir: dir}
+
+	tests := []struct {
+		name   string
+		tamper func(ciphertext, wrappedKey []byte) ([]byte, []byte)
+	}{
+		{
+			name: "ciphertext",
+			tamper: func(ciphertext, wrappedKey []byte) ([]byte, []byte) {
+				ciphertext[len(ciphertext)-1] ^= 0xff
+
+				return ciphertext, wrappedKey
+			},
+		},
+		{
+			name: "data key",
+			tamper: func(ciphertext, wrappedKey []byte) ([]byte, []byte) {
+				wrappedKey[len(wrappedKey)-1] ^= 0xff
+
+				return ciphertext, wrappedKey
+			},
+		},
+		{
+			name: "truncated ciphertext",
+			tamper: func(_, wrappedKey []byte) ([]byte, []byte) {
+				return []byte{1, 2, 3}, wrappedKey
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			ciphertext, wrappedKey, err := Encrypt(provider, "k1", []byte("secret"))
+			is.NoErr(err)
+
+			ciphertext, wrappedKey = tt.tamper(ciphertext, wrappedKey)
+
+			_, err = Decrypt(provider, "k1", ciphertext, wrappedKey)
+			is.True(err != nil)
+		})
+	}
+}
+
+func TestFileKeyProvider_Key(t *testing.T) {
+	dir := t.TempDir()
+	want := writeKey(t, dir, "valid", 32)
+
+	err := os.WriteFile(filepath.Join(dir, "short"), []byte(base64.StdEncoding.EncodeToString([]byte("short"))), 0o600)
+	if err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "valid key",
+			id:   "valid",
+			want: want,
+		},
+		{
+			name:    "missing key",
+			id:      "missing",
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name:    "invalid key size",
+			id:      "short",
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "path traversal",
+			id:      "../valid",
+			wantErr: ErrInvalidKeyID,
+		},
+		{
+			name:    "empty id",
+			id:      "",
+			wantErr: ErrInvalidKeyID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			provider := &FileKeyProvider{Dir: dir}
+
+			got, err := provider.Key(tt.id)
+			if tt.wantErr != nil {
+				is.True(errors.Is(err, tt.wantErr))
+
+				return
+			}
+
+			is.NoErr(err)
+			is.Equal(got, tt.want)
+		})
+	}
+}
+
+func TestEnvKeyProvider_Key(t *testing.T) {
+	is := is.New(t)
+
+	key := bytes.Repeat([]byte{7}, 16)
+	t.Setenv("TEST_KEY_k1", base64.StdEncoding.EncodeToString(key))
+	t.Setenv("TEST_KEY_bad", "not base64!")
+
+	provider := &EnvKeyProvider{Prefix: "TEST_KEY_"}
+
+	got, err := provider.Key("k1")
+	is.NoErr(err)
+	is.Equal(got, key)
+
+	_, err = provider.Key("k2")
+	is.True(errors.Is(err, ErrKeyNotFound))
+
+	_, err = provider.Key("bad")
+	is.True(errors.Is(err, ErrInvalidKey))
+}
+
+func TestEncryptionConfig_Validate(t *testing.T) {
+	is := is.New(t)
+
+	is.NoErr(EncryptionConfig{KeyProvider: KeyProviderNone}.Validate())
+	is.NoErr(EncryptionConfig{KeyProvider: KeyProviderEnv, KeyEnvPrefix: "KEY_"}.Validate())
+	is.NoErr(EncryptionConfig{KeyProvider: KeyProviderFile, KeyDir: "/keys"}.Validate())
+	is.True(errors.Is(EncryptionConfig{KeyProvider: KeyProviderFile}.Validate(), ErrKeyDirRequired))
+}
@@ -0,0 +1,75 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Names of NATS message headers the source and destination use to exchange
+// information about messages beyond their payload.
+const (
+	// HeaderContentEncoding is the compression codec the message payload is encoded with.
+	HeaderContentEncoding = "Content-Encoding"
+	// HeaderContentType is the media type of the message payload.
+	HeaderContentType = "Content-Type"
+
+	// HeaderEncryptionKeyID is the id of the key the message data key is encrypted with.
+	HeaderEncryptionKeyID = "Conduit-Encryption-Key-Id"
+	// HeaderEncryptionDataKey is the base64-encoded encrypted data key of the message.
+	HeaderEncryptionDataKey = "Conduit-Encryption-Data-Key"
+
+	// HeaderChunkID identifies all the chunks of a message that was split
+	// because it exceeded the server's max payload.
+	HeaderChunkID = "Conduit-Chunk-Id"
+	// HeaderChunkIndex is the zero-based position of a chunk within its message.
+	HeaderChunkIndex = "Conduit-Chunk-Index"
+	// HeaderChunkCount is the total number of chunks of a message.
+	HeaderChunkCount = "Conduit-Chunk-Count"
+
+	// HeaderOversizeSubject is the subject an oversized message was meant to be
+	// published to before it was diverted to the overflow subject.
+	HeaderOversizeSubject = "Conduit-Oversize-Subject"
+	// HeaderOversizeBytes is the size of an oversized message in bytes.
+	HeaderOversizeBytes = "Conduit-Oversize-Bytes"
+
+	// HeaderSchemaSubject is the subject of the schema the message payload is encoded with.
+	HeaderSchemaSubject = "Conduit-Schema-Subject"
+	// HeaderSchemaVersion is the version of the schema the message payload is encoded with.
+	HeaderSchemaVersion = "Conduit-Schema-Version"
+	// HeaderSchemaID is the id of the schema the message payload is encoded with.
+	HeaderSchemaID = "Conduit-Schema-Id"
+)
+
+// AvroContentType is the media type of message payloads encoded with an Avro schema.
+const AvroContentType = "application/avro"
+
+// HeaderValue returns the first value of the header with the name. Header names
+// are case-sensitive in NATS, so if there's no exact match, the case is ignored.
+func HeaderValue(header nats.Header, name string) string {
+	if value := header.Get(name); value != "" {
+		return value
+	}
+
+	for k, values := range header {
+		if strings.EqualFold(k, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return ""
+}
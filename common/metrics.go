@@ -0,0 +1,197 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ErrInvalidMetricsAddress occurs when metrics.address isn't a host and port.
+var ErrInvalidMetricsAddress = errors.New("metrics.address must be a host and port, e.g. :9464")
+
+// Reasons of dropped messages, used as the value of the reason label.
+const (
+	DropReasonDelete         = "delete"
+	DropReasonUnmatched      = "unmatched"
+	DropReasonOversize       = "oversize"
+	DropReasonSlowConsumer   = "slow_consumer"
+	DropReasonInvalidChunk   = "invalid_chunk"
+	DropReasonChunkTimeout   = "chunk_timeout"
+	DropReasonChunkMemoryCap = "chunk_memory_cap"
+)
+
+// metricsRegistry holds the connector's metrics, separately from the metrics of the libraries it uses.
+var metricsRegistry = prometheus.NewRegistry()
+
+// The connector's metrics. The source and the destination of a process update the same metrics.
+var (
+	// MetricMessagesReceived counts the messages received by the source, by subscribed subject.
+	MetricMessagesReceived = promauto.With(metricsRegistry).NewCounterVec(prometheus.CounterOpts{
+		Name: "nats_pubsub_messages_received_total",
+		Help: "Number of messages received by the source.",
+	}, []string{"subject"})
+	// MetricBytesReceived counts the payload bytes received by the source, by subscribed subject.
+	MetricBytesReceived = promauto.With(metricsRegistry).NewCounterVec(prometheus.CounterOpts{
+		Name: "nats_pubsub_received_bytes_total",
+		Help: "Number of payload bytes received by the source.",
+	}, []string{"subject"})
+	// MetricMessagesPublished counts the messages published by the destination, by subject.
+	MetricMessagesPublished = promauto.With(metricsRegistry).NewCounterVec(prometheus.CounterOpts{
+		Name: "nats_pubsub_messages_published_total",
+		Help: "Number of messages published by the destination.",
+	}, []string{"subject"})
+	// MetricBytesPublished counts the payload bytes published by the destination, by subject.
+	MetricBytesPublished = promauto.With(metricsRegistry).NewCounterVec(prometheus.CounterOpts{
+		Name: "nats_pubsub_published_bytes_total",
+		Help: "Number of payload bytes published by the destination.",
+	}, []string{"subject"})
+	// MetricMessagesDropped counts the messages dropped by the source or the destination, by reason.
+	MetricMessagesDropped = promauto.With(metricsRegistry).NewCounterVec(prometheus.CounterOpts{
+		Name: "nats_pubsub_messages_dropped_total",
+		Help: "Number of messages dropped instead of being read or published.",
+	}, []string{"reason"})
+	// MetricPendingMessages is the number of received messages waiting to be read by the source, by subscribed subject.
+	MetricPendingMessages = promauto.With(metricsRegistry).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "nats_pubsub_pending_messages",
+		Help: "Number of received messages waiting to be read by the source.",
+	}, []string{"subject"})
+	// MetricReconnectBufferedMessages is the number of messages published by the destination
+	// while disconnected and not yet confirmed by the server.
+	MetricReconnectBufferedMessages = promauto.With(metricsRegistry).NewGauge(prometheus.GaugeOpts{
+		Name: "nats_pubsub_reconnect_buffered_messages",
+		Help: "Number of messages in the reconnect buffer of the destination.",
+	})
+	// MetricSlowConsumerEvents counts the times the source couldn't keep up with the received messages.
+	MetricSlowConsumerEvents = promauto.With(metricsRegistry).NewCounter(prometheus.CounterOpts{
+		Name: "nats_pubsub_slow_consumer_events_total",
+		Help: "Number of slow consumer errors reported for the subscriptions of the source.",
+	})
+	// MetricReconnects counts the reconnects of the connections to NATS.
+	MetricReconnects = promauto.With(metricsRegistry).NewCounter(prometheus.CounterOpts{
+		Name: "nats_pubsub_reconnects_total",
+		Help: "Number of reconnects of the connections to NATS.",
+	})
+	// MetricPublishDuration is the time the destination took to publish a batch of records and get it confirmed.
+	MetricPublishDuration = promauto.With(metricsRegistry).NewHistogram(prometheus.HistogramOpts{
+		Name:    "nats_pubsub_publish_duration_seconds",
+		Help:    "Time to publish a batch of records and get it confirmed by the server.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// MetricsConfig holds the configuration of serving the connector's metrics.
+type MetricsConfig struct {
+	// The address the connector's metrics are served at in the Prometheus text
+	// format under /metrics, e.g. ":9464". If empty, the metrics aren't served.
+	// Connectors running in the same process with the same address share the endpoint.
+	Address string `json:"address"`
+}
+
+// Validate checks the metrics config.
+func (c MetricsConfig) Validate() error {
+	if c.Address == "" {
+		return nil
+	}
+
+	if _, _, err := net.SplitHostPort(c.Address); err != nil {
+		return fmt.Errorf("%q: %w", c.Address, ErrInvalidMetricsAddress)
+	}
+
+	return nil
+}
+
+// metricsServers are the running metrics endpoints, keyed by address.
+var metricsServers = struct {
+	mu      sync.Mutex
+	servers map[string]*metricsServer
+}{servers: make(map[string]*metricsServer)}
+
+// metricsServer is a metrics endpoint and the number of connectors using it.
+type metricsServer struct {
+	srv  *http.Server
+	refs int
+}
+
+// ServeMetrics serves the connector's metrics at the configured address, unless it's empty.
+// The returned function stops serving them, once every connector using the address called it.
+func (c MetricsConfig) ServeMetrics() (func(), error) {
+	if c.Address == "" {
+		return func() {}, nil
+	}
+
+	metricsServers.mu.Lock()
+	defer metricsServers.mu.Unlock()
+
+	s, ok := metricsServers.servers[c.Address]
+	if !ok {
+		ln, err := net.Listen("tcp", c.Address)
+		if err != nil {
+			return nil, fmt.Errorf("listen on %s: %w", c.Address, err)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+
+		s = &metricsServer{srv: &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}}
+		metricsServers.servers[c.Address] = s
+
+		go func() { _ = s.srv.Serve(ln) }()
+	}
+
+	s.refs++
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			metricsServers.mu.Lock()
+			defer metricsServers.mu.Unlock()
+
+			s.refs--
+			if s.refs == 0 {
+				delete(metricsServers.servers, c.Address)
+				_ = s.srv.Close()
+			}
+		})
+	}, nil
+}
+
+// countReconnects returns an option counting the reconnects
+// before calling the handler set by the preceding options.
+func countReconnects() nats.Option {
+	return func(o *nats.Options) error {
+		prev := o.ReconnectedCB
+
+		o.ReconnectedCB = func(conn *nats.Conn) {
+			MetricReconnects.Inc()
+
+			if prev != nil {
+				prev(conn)
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr error
+	}{
+		{name: "empty"},
+		{name: "port only", address: ":9464"},
+		{name: "host and port", address: "127.0.0.1:9464"},
+		{name: "no port", address: "127.0.0.1", wantErr: ErrInvalidMetricsAddress},
+		{name: "url", address: "http://127.0.0.1:9464/metrics", wantErr: ErrInvalidMetricsAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			err := MetricsConfig{Address: tt.address}.Validate()
+			if tt.wantErr != nil {
+				is.True(errors.Is(err, tt.wantErr))
+
+				return
+			}
+			is.NoErr(err)
+		})
+	}
+}
+
+// scrape returns the metrics served at the address, and false if they can't be fetched.
+func scrape(t *testing.T, address string) (string, bool) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://"+address+"/metrics", nil)
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+
+	return string(body), true
+}
+
+func TestMetricsConfig_ServeMetrics(t *testing.T) {
+	is := is.New(t)
+
+	cfg := MetricsConfig{Address: fmt.Sprintf("127.0.0.1:%d", freePort(t))}
+
+	stopFirst, err := cfg.ServeMetrics()
+	is.NoErr(err)
+
+	// a second connector shares the endpoint
+	stopSecond, err := cfg.ServeMetrics()
+	is.NoErr(err)
+
+	// the counter is global, so the test checks its increase
+	counter := MetricMessagesPublished.WithLabelValues("metrics.serve")
+	want := testutil.ToFloat64(counter) + 1
+	counter.Inc()
+
+	body, ok := scrape(t, cfg.Address)
+	is.True(ok)
+	is.True(strings.Contains(body,
+		`nats_pubsub_messages_published_total{subject="metrics.serve"} `+strconv.FormatFloat(want, 'g', -1, 64)))
+
+	// stopping twice doesn't stop the endpoint of the other connector
+	stopFirst()
+	stopFirst()
+
+	_, ok = scrape(t, cfg.Address)
+	is.True(ok)
+
+	stopSecond()
+
+	_, ok = scrape(t, cfg.Address)
+	is.True(!ok)
+
+	// the address is free again
+	ln, err := net.Listen("tcp", cfg.Address)
+	is.NoErr(err)
+	is.NoErr(ln.Close())
+}
+
+func TestConfig_Connect_CountsReconnects(t *testing.T) {
+	is := is.New(t)
+
+	srv := startServer(t)
+	port := srv.Addr().(*net.TCPAddr).Port
+
+	conn, err := Config{
+		URLs:          []string{srv.ClientURL()},
+		MaxReconnects: -1,
+		ReconnectWait: 50 * time.Millisecond,
+	}.Connect(context.Background())
+	is.NoErr(err)
+
+	defer conn.Close()
+
+	before := testutil.ToFloat64(MetricReconnects)
+
+	srv.Shutdown()
+
+	srv, err = server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, NoLog: true, NoSigs: true})
+	is.NoErr(err)
+
+	t.Cleanup(srv.Shutdown)
+
+	go srv.Start()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.ToFloat64(MetricReconnects) == before {
+		if time.Now().After(deadline) {
+			t.Fatal("reconnect wasn't counted")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
 )
 
 // ConnectionOptions returns connection options based on the provided config.
@@ -28,7 +29,116 @@ func (c Config) ConnectionOptions() ([]nats.Option, error) {
 		opts = append(opts, nats.Name(c.ConnectionName))
 	}
 
+	authOpts, err := c.authOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, authOpts...)
+
+	tlsOpts, err := c.TLS.options()
+	if err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
+
+	opts = append(opts, tlsOpts...)
+
+	proxyDialer, err := c.Proxy.dialer()
+	if err != nil {
+		return nil, err
+	}
+
+	if proxyDialer != nil {
+		opts = append(opts, nats.SetCustomDialer(proxyDialer))
+	}
+
+	if c.WebSocket.ProxyPath != "" {
+		opts = append(opts, nats.ProxyPath(c.WebSocket.ProxyPath))
+	}
+
+	if c.WebSocket.Compression {
+		opts = append(opts, nats.Compression(true))
+	}
+
+	return append(opts, c.tuningOptions()...), nil
+}
+
+// tuningOptions returns the options of the connection, ping and reconnect behavior.
+// Zero values keep the NATS defaults.
+func (c Config) tuningOptions() []nats.Option {
+	opts := []nats.Option{
+		nats.MaxReconnects(c.MaxReconnects),
+		nats.ReconnectWait(c.ReconnectWait),
+	}
+
+	if c.ReconnectJitter != 0 || c.ReconnectJitterTLS != 0 {
+		opts = append(opts, nats.ReconnectJitter(c.ReconnectJitter, c.ReconnectJitterTLS))
+	}
+
+	if c.ReconnectBufferSize != 0 {
+		opts = append(opts, nats.ReconnectBufSize(c.ReconnectBufferSize))
+	}
+
+	if c.ConnectTimeout != 0 {
+		opts = append(opts, nats.Timeout(c.ConnectTimeout))
+	}
+
+	if c.PingInterval != 0 {
+		opts = append(opts, nats.PingInterval(c.PingInterval))
+	}
+
+	if c.MaxPingsOutstanding != 0 {
+		opts = append(opts, nats.MaxPingsOutstanding(c.MaxPingsOutstanding))
+	}
+
+	if c.FlusherTimeout != 0 {
+		opts = append(opts, nats.FlusherTimeout(c.FlusherTimeout))
+	}
+
+	if c.RetryOnFailedConnect {
+		opts = append(opts, nats.RetryOnFailedConnect(true))
+	}
+
+	if c.DontRandomize {
+		opts = append(opts, nats.DontRandomize())
+	}
+
+	if c.NoEcho {
+		opts = append(opts, nats.NoEcho())
+	}
+
+	if c.InboxPrefix != "" {
+		opts = append(opts, nats.CustomInboxPrefix(c.InboxPrefix))
+	}
+
+	return opts
+}
+
+// authOptions returns the options of the configured authentication method.
+func (c Config) authOptions() ([]nats.Option, error) {
+	var opts []nats.Option
+
+	if c.Username != "" {
+		password, err := resolveValue(c.Password)
+		if err != nil {
+			return nil, fmt.Errorf("resolve password: %w", err)
+		}
+
+		opts = append(opts, nats.UserInfo(c.Username, password))
+	}
+
+	if c.Token != "" {
+		token, err := resolveValue(c.Token)
+		if err != nil {
+			return nil, fmt.Errorf("resolve token: %w", err)
+		}
+
+		opts = append(opts, nats.Token(token))
+	}
+
 	if c.NKeyPath != "" {
+		// the public key is read once, the client can't change it on reconnects,
+		// so a rotated seed file is picked up only after a restart
 		opt, err := nats.NkeyOptionFromSeed(c.NKeyPath)
 		if err != nil {
 			return nil, fmt.Errorf("load NKey pair: %w", err)
@@ -38,22 +148,62 @@ func (c Config) ConnectionOptions() ([]nats.Option, error) {
 	}
 
 	if c.CredentialsFilePath != "" {
+		// the credentials file is read on every connect, so rotated credentials are picked up
 		opts = append(opts, nats.UserCredentials(c.CredentialsFilePath))
 	}
 
-	if c.TLS.ClientCertPath != "" && c.TLS.ClientPrivateKeyPath != "" {
-		opts = append(opts, nats.ClientCert(
-			c.TLS.ClientCertPath,
-			c.TLS.ClientPrivateKeyPath,
-		))
+	if c.NKeySeed != "" {
+		opt, err := c.inlineAuthOption()
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, opt)
 	}
 
-	if c.TLS.RootCACertPath != "" {
-		opts = append(opts, nats.RootCAs(c.TLS.RootCACertPath))
+	return opts, nil
+}
+
+// inlineAuthOption returns the option authenticating with the inline NKey seed,
+// and the user JWT if configured. Errors never contain the seed.
+func (c Config) inlineAuthOption() (nats.Option, error) {
+	seed, err := resolveValue(c.NKeySeed)
+	if err != nil {
+		return nil, fmt.Errorf("resolve NKey seed: %w", err)
 	}
 
-	opts = append(opts, nats.MaxReconnects(c.MaxReconnects))
-	opts = append(opts, nats.ReconnectWait(c.ReconnectWait))
+	kp, err := nkeys.FromSeed([]byte(seed))
+	if err != nil {
+		return nil, fmt.Errorf("parse NKey seed: %w", err)
+	}
+	defer kp.Wipe()
 
-	return opts, nil
+	if c.JWT != "" {
+		jwt, err := resolveValue(c.JWT)
+		if err != nil {
+			return nil, fmt.Errorf("resolve JWT: %w", err)
+		}
+
+		return nats.UserJWTAndSeed(jwt, seed), nil
+	}
+
+	publicKey, err := kp.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("get NKey public key: %w", err)
+	}
+
+	return nats.Nkey(publicKey, func(nonce []byte) ([]byte, error) {
+		kp, err := nkeys.FromSeed([]byte(seed))
+		if err != nil {
+			return nil, fmt.Errorf("parse NKey seed: %w", err)
+		}
+		defer kp.Wipe()
+
+		sig, err := kp.Sign(nonce)
+		if err != nil {
+			return nil, fmt.Errorf("sign nonce: %w", err)
+		}
+
+		return sig, nil
+	}), nil
 }
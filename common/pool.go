@@ -0,0 +1,316 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// permissionSubjectRe matches the subject of a permission violation, e.g.
+// `Permissions Violation for Publish to "foo"`.
+var permissionSubjectRe = regexp.MustCompile(`(?:Publish|Subscription) to "(\S+)"`)
+
+// connectionNameKey is the random key shared connection names are derived with.
+var connectionNameKey = sync.OnceValue(func() []byte {
+	key := make([]byte, sha256.Size)
+	// crypto/rand.Read never fails, it crashes the program if it can't read
+	_, _ = rand.Read(key)
+
+	return key
+})
+
+// sharedConns is the process-wide pool of connections shared by the connectors
+// with sharedConnection set.
+var sharedConns = &connPool{entries: make(map[string]*poolEntry)}
+
+// connPool holds the shared connections keyed by their connection settings.
+type connPool struct {
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+}
+
+// poolEntry is a shared connection and the leases of the connectors using it.
+type poolEntry struct {
+	pool  *connPool
+	key   string
+	conn  *nats.Conn
+	state *connState
+
+	mu     sync.Mutex
+	leases map[*lease]struct{}
+}
+
+// lease is a connector's use of a shared connection, with the connector's handlers
+// and subscriptions.
+type lease struct {
+	entry    *poolEntry
+	handlers nats.Options
+	subs     []*nats.Subscription
+	once     sync.Once
+}
+
+// acquire returns a lease of the shared connection with the config's connection
+// settings, and connects if there's no such connection yet.
+func (p *connPool) acquire(ctx context.Context, c Config, extraOpts []nats.Option) (*Conn, error) {
+	var handlers nats.Options
+	for _, opt := range extraOpts {
+		if err := opt(&handlers); err != nil {
+			return nil, fmt.Errorf("apply connection option: %w", err)
+		}
+	}
+
+	key, err := c.poolKey()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+
+	entry, ok := p.entries[key]
+	if !ok || entry.conn.IsClosed() {
+		entry, err = p.dial(c, key)
+		if err != nil {
+			p.mu.Unlock()
+
+			return nil, err
+		}
+
+		p.entries[key] = entry
+	}
+
+	l := entry.add(handlers)
+
+	p.mu.Unlock()
+
+	if err := c.waitConnected(ctx, entry.conn, entry.state); err != nil {
+		l.release()
+
+		return nil, err
+	}
+
+	return &Conn{Conn: entry.conn, lease: l}, nil
+}
+
+// dial connects a new shared connection dispatching the connection events to its leases.
+func (p *connPool) dial(c Config, key string) (*poolEntry, error) {
+	entry := &poolEntry{
+		pool:   p,
+		key:    key,
+		leases: make(map[*lease]struct{}),
+	}
+
+	conn, state, err := c.dial(entry.options()...)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.conn, entry.state = conn, state
+
+	return entry, nil
+}
+
+// remove removes the entry from the pool, unless it was already replaced.
+func (p *connPool) remove(entry *poolEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.entries[entry.key] == entry {
+		delete(p.entries, entry.key)
+	}
+}
+
+// poolKey returns the key identifying connections with the config's connection settings.
+func (c Config) poolKey() (string, error) {
+	// the subject and the startup wait don't affect the connection
+	c.Subject, c.StartupWait = "", 0
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("marshal connection settings: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sharedConnectionName returns the name of shared connections with the config's connection
+// settings. The settings contain secrets, so the name is derived from them with a random
+// key generated once per process, which keeps the name from revealing anything about them.
+func (c Config) sharedConnectionName() (string, error) {
+	key, err := c.poolKey()
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, connectionNameKey())
+	mac.Write([]byte(key))
+
+	return DefaultConnectionNamePrefix + "shared-" + hex.EncodeToString(mac.Sum(nil))[:16], nil
+}
+
+// add adds a lease with the handlers to the entry.
+func (e *poolEntry) add(handlers nats.Options) *lease {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	l := &lease{entry: e, handlers: handlers}
+	e.leases[l] = struct{}{}
+
+	return l
+}
+
+// each calls the function with the handlers of every lease.
+func (e *poolEntry) each(fn func(handlers nats.Options)) {
+	e.mu.Lock()
+	handlers := make([]nats.Options, 0, len(e.leases))
+	for l := range e.leases {
+		handlers = append(handlers, l.handlers)
+	}
+	e.mu.Unlock()
+
+	for _, h := range handlers {
+		fn(h)
+	}
+}
+
+// eachSubscribed calls the function with the handlers of every lease subscribed to the subject.
+func (e *poolEntry) eachSubscribed(subject string, fn func(handlers nats.Options)) {
+	e.mu.Lock()
+	var handlers []nats.Options
+	for l := range e.leases {
+		for _, sub := range l.subs {
+			if sub.Subject == subject {
+				handlers = append(handlers, l.handlers)
+
+				break
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	for _, h := range handlers {
+		fn(h)
+	}
+}
+
+// options returns the connection options dispatching the connection events to the leases.
+func (e *poolEntry) options() []nats.Option {
+	return []nats.Option{
+		nats.ConnectHandler(func(conn *nats.Conn) {
+			e.each(func(h nats.Options) {
+				if h.ConnectedCB != nil {
+					h.ConnectedCB(conn)
+				}
+			})
+		}),
+		nats.DisconnectErrHandler(func(conn *nats.Conn, err error) {
+			e.each(func(h nats.Options) {
+				if h.DisconnectedErrCB != nil {
+					h.DisconnectedErrCB(conn, err)
+				}
+			})
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			e.each(func(h nats.Options) {
+				if h.ReconnectedCB != nil {
+					h.ReconnectedCB(conn)
+				}
+			})
+		}),
+		nats.ClosedHandler(func(conn *nats.Conn) {
+			e.pool.remove(e)
+			e.each(func(h nats.Options) {
+				if h.ClosedCB != nil {
+					h.ClosedCB(conn)
+				}
+			})
+		}),
+		nats.ErrorHandler(func(conn *nats.Conn, sub *nats.Subscription, err error) {
+			notify := func(h nats.Options) {
+				if h.AsyncErrorCB != nil {
+					h.AsyncErrorCB(conn, sub, err)
+				}
+			}
+
+			// permission violations come without a subscription, they concern
+			// only the connectors subscribed to the denied subject
+			if sub == nil && errors.Is(err, nats.ErrPermissionViolation) {
+				if m := permissionSubjectRe.FindStringSubmatch(err.Error()); m != nil {
+					e.eachSubscribed(m[1], notify)
+				}
+
+				return
+			}
+
+			e.each(notify)
+		}),
+	}
+}
+
+// setErrorHandler sets the lease's handler of asynchronous errors.
+func (l *lease) setErrorHandler(cb nats.ErrHandler) {
+	l.entry.mu.Lock()
+	defer l.entry.mu.Unlock()
+
+	l.handlers.AsyncErrorCB = cb
+}
+
+// addSubscription records a subscription of the lease's connector.
+func (l *lease) addSubscription(sub *nats.Subscription) {
+	l.entry.mu.Lock()
+	defer l.entry.mu.Unlock()
+
+	l.subs = append(l.subs, sub)
+}
+
+// release ends the lease. The last lease closes the connection, its closed
+// handler is called, other leases leave without their closed handlers called.
+func (l *lease) release() {
+	l.once.Do(func() {
+		e := l.entry
+
+		e.pool.mu.Lock()
+		e.mu.Lock()
+
+		last := len(e.leases) == 1
+		if !last {
+			delete(e.leases, l)
+		}
+
+		e.mu.Unlock()
+
+		if last && e.pool.entries[e.key] == e {
+			delete(e.pool.entries, e.key)
+		}
+
+		e.pool.mu.Unlock()
+
+		if last {
+			e.conn.Close()
+		}
+	})
+}
@@ -0,0 +1,212 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// signal sends to the channel without blocking the connection's handlers.
+func signal(c chan<- struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// waitSignal fails the test if nothing is sent to the channel in time.
+func waitSignal(t *testing.T, c <-chan struct{}, what string) {
+	t.Helper()
+
+	select {
+	case <-c:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s wasn't called", what)
+	}
+}
+
+func TestConfig_Connect_Shared(t *testing.T) {
+	is := is.New(t)
+
+	srv := startServer(t)
+
+	cfg := Config{URLs: []string{srv.ClientURL()}, Subject: "a", SharedConnection: true}
+
+	first, err := cfg.Connect(context.Background())
+	is.NoErr(err)
+
+	cfg.Subject = "b"
+
+	second, err := cfg.Connect(context.Background())
+	is.NoErr(err)
+
+	// a different subject shares the connection, a different connection name doesn't
+	is.True(first.Conn == second.Conn)
+
+	cfg.ConnectionName = "other"
+
+	other, err := cfg.Connect(context.Background())
+	is.NoErr(err)
+
+	defer other.Close()
+
+	is.True(other.Conn != first.Conn)
+
+	// unshared connections are never shared
+	cfg.SharedConnection = false
+
+	unshared, err := cfg.Connect(context.Background())
+	is.NoErr(err)
+
+	defer unshared.Close()
+
+	is.True(unshared.Conn != other.Conn)
+
+	// the connection stays open for the remaining connectors
+	subB, err := second.SubscribeSync("b")
+	is.NoErr(err)
+
+	first.Close()
+	is.True(!second.IsClosed())
+
+	is.NoErr(second.Publish("b", []byte("hello")))
+
+	msg, err := subB.NextMsg(2 * time.Second)
+	is.NoErr(err)
+	is.Equal(string(msg.Data), "hello")
+
+	// closing twice doesn't release the lease of another connector
+	first.Close()
+	is.True(!second.IsClosed())
+
+	second.Close()
+	is.True(second.IsClosed())
+
+	// a new connector gets a new connection
+	third, err := Config{URLs: []string{srv.ClientURL()}, Subject: "a", SharedConnection: true}.Connect(context.Background())
+	is.NoErr(err)
+
+	defer third.Close()
+
+	is.True(third.Conn != first.Conn)
+	is.True(third.IsConnected())
+}
+
+func TestConfig_GetConnectionName_Shared(t *testing.T) {
+	is := is.New(t)
+
+	cfg := Config{URLs: []string{"nats://127.0.0.1:4222"}, Password: "secret", SharedConnection: true}
+
+	first, second := cfg, cfg
+	second.Subject = "other"
+	is.Equal(first.GetConnectionName(), second.GetConnectionName())
+
+	other := cfg
+	other.Password = "different"
+	is.True(other.GetConnectionName() != first.GetConnectionName())
+
+	// the name isn't the plain hash of the settings, which contain secrets
+	key, err := cfg.poolKey()
+	is.NoErr(err)
+	is.True(!strings.Contains(first.GetConnectionName(), key[:16]))
+}
+
+func TestConfig_Connect_SharedHandlers(t *testing.T) {
+	is := is.New(t)
+
+	srv := startServer(t)
+	port := srv.Addr().(*net.TCPAddr).Port
+
+	cfg := Config{
+		URLs:             []string{srv.ClientURL()},
+		Subject:          "foo",
+		MaxReconnects:    -1,
+		ReconnectWait:    50 * time.Millisecond,
+		SharedConnection: true,
+	}
+
+	type signals struct {
+		reconnected chan struct{}
+		closed      chan struct{}
+		errors      chan struct{}
+	}
+
+	connect := func() (*Conn, signals) {
+		s := signals{
+			reconnected: make(chan struct{}, 1),
+			closed:      make(chan struct{}, 1),
+			errors:      make(chan struct{}, 1),
+		}
+
+		conn, err := cfg.Connect(context.Background(),
+			nats.ReconnectHandler(func(*nats.Conn) { signal(s.reconnected) }),
+			nats.ClosedHandler(func(*nats.Conn) { signal(s.closed) }),
+		)
+		is.NoErr(err)
+
+		conn.SetErrorHandler(func(*nats.Conn, *nats.Subscription, error) { signal(s.errors) })
+
+		return conn, s
+	}
+
+	first, firstSignals := connect()
+	second, secondSignals := connect()
+	is.True(first.Conn == second.Conn)
+
+	// every connector is notified about a reconnect
+	srv.Shutdown()
+
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, NoLog: true, NoSigs: true})
+	is.NoErr(err)
+
+	t.Cleanup(srv.Shutdown)
+
+	go srv.Start()
+
+	waitSignal(t, firstSignals.reconnected, "first reconnect handler")
+	waitSignal(t, secondSignals.reconnected, "second reconnect handler")
+
+	// and about async errors
+	sub, err := second.Subscribe("slow", func(*nats.Msg) { time.Sleep(time.Second) })
+	is.NoErr(err)
+	is.NoErr(sub.SetPendingLimits(1, -1))
+
+	for range 3 {
+		is.NoErr(first.Publish("slow", nil))
+	}
+
+	waitSignal(t, firstSignals.errors, "first error handler")
+	waitSignal(t, secondSignals.errors, "second error handler")
+
+	// only the last connector is notified about the closed connection
+	first.Close()
+	second.Close()
+
+	waitSignal(t, secondSignals.closed, "second closed handler")
+
+	select {
+	case <-firstSignals.closed:
+		t.Fatal("closed handler of a released connector was called")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+const (
+	// ProxySchemeSOCKS5 is the scheme of SOCKS5 proxy URLs.
+	ProxySchemeSOCKS5 = "socks5"
+	// ProxySchemeHTTP is the scheme of HTTP CONNECT proxy URLs.
+	ProxySchemeHTTP = "http"
+)
+
+var (
+	// ErrInvalidProxyURL occurs when the proxy URL can't be parsed, has an unsupported scheme or no port.
+	ErrInvalidProxyURL = errors.New(`proxy.url must be a "socks5://host:port" or "http://host:port" URL`)
+	// ErrProxySettingsWithoutURL occurs when proxy credentials are configured without a proxy URL.
+	ErrProxySettingsWithoutURL = errors.New("proxy.username and proxy.password require proxy.url")
+	// ErrProxyPasswordWithoutUsername occurs when a proxy password is configured without a username.
+	ErrProxyPasswordWithoutUsername = errors.New("proxy.username is required when proxy.password is set")
+	// ErrProxyConnect occurs when an HTTP proxy refuses to connect to the server.
+	ErrProxyConnect = errors.New("proxy refused to connect")
+)
+
+// ProxyConfig contains the settings of connecting to NATS through a proxy.
+type ProxyConfig struct {
+	// The URL of a proxy to connect through, "socks5://host:port" for a SOCKS5
+	// proxy or "http://host:port" for an HTTP CONNECT proxy.
+	URL string `json:"url"`
+	// A username to authenticate to the proxy with.
+	Username string `json:"username"`
+	// A password to authenticate to the proxy with.
+	// Use "env:NAME" to read it from the environment variable NAME.
+	Password string `json:"password"`
+	// The maximum time to establish a connection through the proxy.
+	Timeout time.Duration `json:"timeout" default:"10s"`
+}
+
+// Validate checks the proxy config values that can't be expressed as parameter validations.
+func (c ProxyConfig) Validate() error {
+	if c.URL == "" {
+		if c.Username != "" || c.Password != "" {
+			return ErrProxySettingsWithoutURL
+		}
+
+		return nil
+	}
+
+	if c.Password != "" && c.Username == "" {
+		return ErrProxyPasswordWithoutUsername
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != ProxySchemeSOCKS5 && u.Scheme != ProxySchemeHTTP) || u.Hostname() == "" || u.Port() == "" {
+		return fmt.Errorf("%q: %w", RedactURL(c.URL), ErrInvalidProxyURL)
+	}
+
+	return nil
+}
+
+// dialer returns the dialer connecting through the proxy, or nil if no proxy is configured.
+func (c ProxyConfig) dialer() (*proxyDialer, error) {
+	if c.URL == "" {
+		return nil, nil //nolint:nilnil // no proxy is a valid configuration
+	}
+
+	proxyURL, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", RedactURL(c.URL), ErrInvalidProxyURL)
+	}
+
+	d := &proxyDialer{
+		scheme:  proxyURL.Scheme,
+		address: proxyURL.Host,
+		timeout: c.Timeout,
+	}
+
+	// explicit credentials take precedence over the ones in the URL
+	switch {
+	case c.Username != "":
+		password, err := resolveValue(c.Password)
+		if err != nil {
+			return nil, fmt.Errorf("resolve proxy password: %w", err)
+		}
+
+		d.username, d.password = c.Username, password
+	case proxyURL.User != nil:
+		d.username = proxyURL.User.Username()
+		d.password, _ = proxyURL.User.Password()
+	}
+
+	return d, nil
+}
+
+// proxyDialer is a nats.CustomDialer connecting through a SOCKS5 or HTTP CONNECT proxy.
+type proxyDialer struct {
+	scheme   string
+	address  string
+	username string
+	password string
+	timeout  time.Duration
+}
+
+// Dial connects to the address through the proxy.
+func (d *proxyDialer) Dial(network, address string) (net.Conn, error) {
+	ctx := context.Background()
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	if d.scheme == ProxySchemeSOCKS5 {
+		return d.dialSOCKS5(ctx, network, address)
+	}
+
+	return d.dialHTTPConnect(ctx, address)
+}
+
+// dialSOCKS5 connects to the address through a SOCKS5 proxy.
+func (d *proxyDialer) dialSOCKS5(ctx context.Context, network, address string) (net.Conn, error) {
+	var auth *proxy.Auth
+	if d.username != "" {
+		auth = &proxy.Auth{User: d.username, Password: d.password}
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", d.address, auth, &net.Dialer{})
+	if err != nil {
+		return nil, fmt.Errorf("create SOCKS5 dialer: %w", err)
+	}
+
+	//nolint:forcetypeassert // the SOCKS5 dialer always implements proxy.ContextDialer
+	conn, err := dialer.(proxy.ContextDialer).DialContext(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("connect through SOCKS5 proxy: %w", err)
+	}
+
+	return conn, nil
+}
+
+// dialHTTPConnect connects to the address through an HTTP proxy with the CONNECT method.
+func (d *proxyDialer) dialHTTPConnect(ctx context.Context, address string) (net.Conn, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", d.address)
+	if err != nil {
+		return nil, fmt.Errorf("connect to HTTP proxy: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	br, err := d.httpConnect(conn, address)
+	if err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	// the server might have sent its INFO message together with the proxy response
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+
+	return conn, nil
+}
+
+// httpConnect asks the proxy to connect to the address and returns the reader of the connection.
+func (d *proxyDialer) httpConnect(conn net.Conn, address string) (*bufio.Reader, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+
+	if d.username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.username + ":" + d.password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("send CONNECT request: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, fmt.Errorf("read CONNECT response: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrProxyConnect, resp.Status)
+	}
+
+	return br, nil
+}
+
+// bufferedConn is a net.Conn reading the data buffered while talking to the proxy first.
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b) //nolint:wrapcheck // the reader wraps the connection
+}
@@ -0,0 +1,153 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNoRootCAs occurs when a root CA file doesn't contain any PEM-encoded certificates.
+var ErrNoRootCAs = errors.New("no PEM-encoded certificates found")
+
+// fileVersion identifies the content of a file by its size and modification time.
+type fileVersion struct {
+	size    int64
+	modTime time.Time
+}
+
+// statFiles returns the versions of the files.
+func statFiles(paths ...string) ([]fileVersion, error) {
+	versions := make([]fileVersion, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("stat %q: %w", path, err)
+		}
+
+		versions[i] = fileVersion{size: info.Size(), modTime: info.ModTime()}
+	}
+
+	return versions, nil
+}
+
+// reloader caches a value loaded from files and loads it again when the files change,
+// so every reconnect uses the current content of the files. If loading the changed
+// files fails, e.g. because the files are being rotated, the last loaded value is
+// returned until the files are valid again.
+type reloader[T any] struct {
+	paths []string
+	load  func() (T, error)
+
+	mu       sync.Mutex
+	versions []fileVersion
+	value    T
+	loaded   bool
+}
+
+func newReloader[T any](load func() (T, error), paths ...string) *reloader[T] {
+	return &reloader[T]{
+		paths: paths,
+		load:  load,
+	}
+}
+
+// get returns the value loaded from the current files.
+func (r *reloader[T]) get() (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, err := statFiles(r.paths...)
+	if err != nil {
+		return r.fallback(err)
+	}
+
+	if r.loaded && equalVersions(versions, r.versions) {
+		return r.value, nil
+	}
+
+	value, err := r.load()
+	if err != nil {
+		return r.fallback(err)
+	}
+
+	r.value, r.versions, r.loaded = value, versions, true
+
+	return value, nil
+}
+
+// fallback returns the last loaded value, or the error if nothing was loaded yet.
+func (r *reloader[T]) fallback(err error) (T, error) {
+	if r.loaded {
+		return r.value, nil
+	}
+
+	var zero T
+
+	return zero, err
+}
+
+func equalVersions(a, b []fileVersion) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].size != b[i].size || !a[i].modTime.Equal(b[i].modTime) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newClientCertReloader returns a reloader of the TLS client certificate and its private key.
+func newClientCertReloader(certPath, keyPath string) *reloader[tls.Certificate] {
+	return newReloader(func() (tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("load client certificate: %w", err)
+		}
+
+		cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("parse client certificate: %w", err)
+		}
+
+		return cert, nil
+	}, certPath, keyPath)
+}
+
+// newRootCAsReloader returns a reloader of the root certificate pool.
+func newRootCAsReloader(caPath string) *reloader[*x509.CertPool] {
+	return newReloader(func() (*x509.CertPool, error) {
+		pem, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("read root CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%q: %w", caPath, ErrNoRootCAs)
+		}
+
+		return pool, nil
+	}, caPath)
+}
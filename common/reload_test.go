@@ -0,0 +1,324 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// testCert is a certificate with its private key, both PEM-encoded.
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert creates a certificate signed by the parent, or a self-signed CA if the parent is nil.
+func newTestCert(t *testing.T, commonName string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("generate serial: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{commonName},
+	}
+
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// writeFile writes the file and moves its modification time forward, so a rotation
+// is detected even on file systems with a coarse timestamp resolution.
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	modTime := time.Now()
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime().Add(time.Second)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %q: %v", path, err)
+	}
+
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("change times of %q: %v", path, err)
+	}
+}
+
+func TestClientCertReloader(t *testing.T) {
+	is := is.New(t)
+
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+
+	ca := newTestCert(t, "ca", nil)
+	first := newTestCert(t, "first", ca)
+	second := newTestCert(t, "second", ca)
+
+	r := newClientCertReloader(certPath, keyPath)
+
+	// nothing to fall back to yet
+	_, err := r.get()
+	is.True(err != nil)
+
+	writeFile(t, certPath, first.certPEM)
+	writeFile(t, keyPath, first.keyPEM)
+
+	cert, err := r.get()
+	is.NoErr(err)
+	is.Equal(cert.Leaf.Subject.CommonName, "first")
+
+	// a half-done rotation keeps the last valid certificate
+	writeFile(t, certPath, second.certPEM)
+
+	cert, err = r.get()
+	is.NoErr(err)
+	is.Equal(cert.Leaf.Subject.CommonName, "first")
+
+	writeFile(t, keyPath, second.keyPEM)
+
+	cert, err = r.get()
+	is.NoErr(err)
+	is.Equal(cert.Leaf.Subject.CommonName, "second")
+
+	// a missing file keeps the last valid certificate too
+	is.NoErr(os.Remove(keyPath))
+
+	cert, err = r.get()
+	is.NoErr(err)
+	is.Equal(cert.Leaf.Subject.CommonName, "second")
+}
+
+func TestReloader_LoadsOnlyChangedFiles(t *testing.T) {
+	is := is.New(t)
+
+	path := filepath.Join(t.TempDir(), "value")
+	writeFile(t, path, []byte("first"))
+
+	loads := 0
+	r := newReloader(func() (string, error) {
+		loads++
+		data, err := os.ReadFile(path)
+
+		return string(data), err
+	}, path)
+
+	for range 3 {
+		got, err := r.get()
+		is.NoErr(err)
+		is.Equal(got, "first")
+	}
+
+	is.Equal(loads, 1)
+
+	writeFile(t, path, []byte("second"))
+
+	got, err := r.get()
+	is.NoErr(err)
+	is.Equal(got, "second")
+	is.Equal(loads, 2)
+}
+
+func TestRootCAsReloader(t *testing.T) {
+	is := is.New(t)
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, path, []byte("not a certificate"))
+
+	_, err := newRootCAsReloader(path).get()
+	is.True(err != nil)
+
+	writeFile(t, path, newTestCert(t, "ca", nil).certPEM)
+
+	pool, err := newRootCAsReloader(path).get()
+	is.NoErr(err)
+	is.True(pool != nil)
+}
+
+// startTLSServer starts a NATS server requiring client certificates signed by the client CA.
+func startTLSServer(t *testing.T, port int, serverCert, clientCA *testCert, handshakeFirst bool) *server.Server {
+	t.Helper()
+
+	cert, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	if err != nil {
+		t.Fatalf("load server certificate: %v", err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA.cert)
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      port,
+		NoLog:     true,
+		NoSigs:    true,
+		TLS:       true,
+		TLSVerify: true,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientCAs:    clientCAs,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			MinVersion:   tls.VersionTLS12,
+		},
+		TLSTimeout:        2,
+		TLSHandshakeFirst: handshakeFirst,
+	})
+	if err != nil {
+		t.Fatalf("create server: %v", err)
+	}
+
+	go srv.Start()
+
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("server isn't ready for connections")
+	}
+
+	t.Cleanup(srv.Shutdown)
+
+	return srv
+}
+
+func TestConfig_ConnectionOptions_RotateClientCertMidStream(t *testing.T) {
+	is := is.New(t)
+
+	dir := t.TempDir()
+
+	serverCA := newTestCert(t, "server-ca", nil)
+	oldClientCA := newTestCert(t, "old-client-ca", nil)
+	newClientCA := newTestCert(t, "new-client-ca", nil)
+
+	serverCert := newTestCert(t, "server", serverCA)
+	oldClientCert := newTestCert(t, "old-client", oldClientCA)
+	newClientCert := newTestCert(t, "new-client", newClientCA)
+
+	cfg := Config{
+		TLS: TLSConfig{
+			ClientCertPath:       filepath.Join(dir, "client.pem"),
+			ClientPrivateKeyPath: filepath.Join(dir, "client-key.pem"),
+			RootCACertPath:       filepath.Join(dir, "ca.pem"),
+		},
+		MaxReconnects: -1,
+		ReconnectWait: 50 * time.Millisecond,
+	}
+
+	writeFile(t, cfg.TLS.RootCACertPath, serverCA.certPEM)
+	writeFile(t, cfg.TLS.ClientCertPath, oldClientCert.certPEM)
+	writeFile(t, cfg.TLS.ClientPrivateKeyPath, oldClientCert.keyPEM)
+
+	srv := startTLSServer(t, server.RANDOM_PORT, serverCert, oldClientCA, false)
+	port := srv.Addr().(*net.TCPAddr).Port
+
+	opts, err := cfg.ConnectionOptions()
+	is.NoErr(err)
+
+	reconnected := make(chan struct{}, 1)
+	opts = append(opts, nats.ReconnectHandler(func(*nats.Conn) {
+		reconnected <- struct{}{}
+	}))
+
+	conn, err := nats.Connect(srv.ClientURL(), opts...)
+	is.NoErr(err)
+
+	t.Cleanup(conn.Close)
+
+	sub, err := conn.SubscribeSync("rotation")
+	is.NoErr(err)
+
+	is.NoErr(conn.Publish("rotation", []byte("before")))
+
+	msg, err := sub.NextMsg(2 * time.Second)
+	is.NoErr(err)
+	is.Equal(string(msg.Data), "before")
+
+	// rotate the client certificate, and replace the server with one that
+	// trusts only the new certificate, forcing a reconnect
+	writeFile(t, cfg.TLS.ClientCertPath, newClientCert.certPEM)
+	writeFile(t, cfg.TLS.ClientPrivateKeyPath, newClientCert.keyPEM)
+
+	srv.Shutdown()
+	startTLSServer(t, port, serverCert, newClientCA, false)
+
+	select {
+	case <-reconnected:
+	case <-time.After(10 * time.Second):
+		t.Fatal("connection didn't reconnect with the rotated certificate")
+	}
+
+	is.NoErr(conn.Publish("rotation", []byte("after")))
+
+	msg, err = sub.NextMsg(2 * time.Second)
+	is.NoErr(err)
+	is.Equal(string(msg.Data), "after")
+}
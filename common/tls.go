@@ -0,0 +1,176 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	// ErrUnknownCipherSuite occurs when a cipher suite isn't one of Go's secure TLS 1.2 cipher suites.
+	ErrUnknownCipherSuite = errors.New("unknown or insecure cipher suite")
+	// ErrTLSSourceConflict occurs when a certificate or key is configured both as a path and inline.
+	ErrTLSSourceConflict = errors.New("only one of a path and inline content can be set")
+)
+
+// tlsVersions maps the supported minimum TLS versions to their crypto/tls values.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Validate checks the TLS config values that can't be expressed as parameter validations.
+func (c TLSConfig) Validate() error {
+	for _, name := range c.CipherSuites {
+		if _, ok := cipherSuiteID(name); !ok {
+			return fmt.Errorf("%q: %w", name, ErrUnknownCipherSuite)
+		}
+	}
+
+	conflicts := []struct {
+		name         string
+		path, inline string
+	}{
+		{name: "client certificate", path: c.ClientCertPath, inline: c.ClientCert},
+		{name: "client private key", path: c.ClientPrivateKeyPath, inline: c.ClientPrivateKey},
+		{name: "root CA certificate", path: c.RootCACertPath, inline: c.RootCACert},
+	}
+
+	for _, cc := range conflicts {
+		if cc.path != "" && cc.inline != "" {
+			return fmt.Errorf("%s: %w", cc.name, ErrTLSSourceConflict)
+		}
+	}
+
+	return c.validateKeyPair()
+}
+
+// enabled reports whether any TLS setting requires a secure connection.
+func (c TLSConfig) enabled() bool {
+	return c.ClientCertPath != "" || c.ClientPrivateKeyPath != "" || c.RootCACertPath != "" ||
+		c.ClientCert != "" || c.ClientPrivateKey != "" || c.RootCACert != "" ||
+		c.ServerName != "" || len(c.CipherSuites) > 0 || c.InsecureSkipVerify || c.HandshakeFirst
+}
+
+// options returns the options securing the connection with the TLS config, or none
+// if TLS isn't configured. Certificates configured as paths are loaded again when the
+// files change, so reconnects pick up rotated certificates.
+func (c TLSConfig) options() ([]nats.Option, error) {
+	if !c.enabled() {
+		return nil, nil
+	}
+
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []nats.Option{nats.Secure(tlsConfig)}
+
+	var (
+		certCB    nats.TLSCertHandler
+		rootCAsCB nats.RootCAsHandler
+	)
+
+	if c.ClientCertPath != "" && c.ClientPrivateKeyPath != "" {
+		certCB = newClientCertReloader(c.ClientCertPath, c.ClientPrivateKeyPath).get
+	}
+
+	if c.RootCACertPath != "" {
+		rootCAsCB = newRootCAsReloader(c.RootCACertPath).get
+	}
+
+	if certCB != nil || rootCAsCB != nil {
+		opts = append(opts, nats.ClientTLSConfig(certCB, rootCAsCB))
+	}
+
+	if c.HandshakeFirst {
+		opts = append(opts, nats.TLSHandshakeFirst())
+	}
+
+	return opts, nil
+}
+
+// tlsConfig assembles the tls.Config with the settings and inline certificates.
+func (c TLSConfig) tlsConfig() (*tls.Config, error) {
+	minVersion, ok := tlsVersions[c.MinVersion]
+	if !ok {
+		minVersion = tls.VersionTLS12
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         c.ServerName,
+		MinVersion:         minVersion,
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec // opt-in for development clusters
+	}
+
+	for _, name := range c.CipherSuites {
+		id, ok := cipherSuiteID(name)
+		if !ok {
+			return nil, fmt.Errorf("%q: %w", name, ErrUnknownCipherSuite)
+		}
+
+		tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+	}
+
+	if c.ClientCert != "" && c.ClientPrivateKey != "" {
+		certPEM, err := resolveValue(c.ClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("resolve client certificate: %w", err)
+		}
+
+		keyPEM, err := resolveValue(c.ClientPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("resolve client private key: %w", err)
+		}
+
+		cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("parse client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.RootCACert != "" {
+		caPEM, err := resolveValue(c.RootCACert)
+		if err != nil {
+			return nil, fmt.Errorf("resolve root CA certificate: %w", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM([]byte(caPEM)) {
+			return nil, fmt.Errorf("root CA certificate: %w", ErrNoRootCAs)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// cipherSuiteID returns the id of the secure cipher suite with the name.
+func cipherSuiteID(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+
+	return 0, false
+}
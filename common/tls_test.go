@@ -0,0 +1,245 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+func TestTLSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     TLSConfig
+		wantErr error
+	}{
+		{
+			name: "empty",
+			cfg:  TLSConfig{},
+		},
+		{
+			name: "cipher suites",
+			cfg:  TLSConfig{CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}},
+		},
+		{
+			name:    "insecure cipher suite",
+			cfg:     TLSConfig{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			wantErr: ErrUnknownCipherSuite,
+		},
+		{
+			name:    "unknown cipher suite",
+			cfg:     TLSConfig{CipherSuites: []string{"TLS_FOO"}},
+			wantErr: ErrUnknownCipherSuite,
+		},
+		{
+			name:    "client certificate path and inline",
+			cfg:     TLSConfig{ClientCertPath: "./client.pem", ClientCert: "env:CLIENT_CERT"},
+			wantErr: ErrTLSSourceConflict,
+		},
+		{
+			name:    "root CA path and inline",
+			cfg:     TLSConfig{RootCACertPath: "./ca.pem", RootCACert: "env:ROOT_CA"},
+			wantErr: ErrTLSSourceConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			err := tt.cfg.Validate()
+			if tt.wantErr == nil {
+				is.NoErr(err)
+
+				return
+			}
+
+			is.True(errors.Is(err, tt.wantErr))
+		})
+	}
+}
+
+func TestTLSConfig_options(t *testing.T) {
+	is := is.New(t)
+
+	opts, err := TLSConfig{MinVersion: "1.2"}.options()
+	is.NoErr(err)
+	is.Equal(len(opts), 0) // TLS isn't configured
+
+	opts, err = TLSConfig{
+		ServerName:         "nats.internal",
+		MinVersion:         "1.3",
+		CipherSuites:       []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+		InsecureSkipVerify: true,
+		HandshakeFirst:     true,
+	}.options()
+	is.NoErr(err)
+
+	options := applyOptions(t, opts)
+	is.True(options.Secure)
+	is.True(options.TLSHandshakeFirst)
+	is.Equal(options.TLSConfig.ServerName, "nats.internal")
+	is.Equal(options.TLSConfig.MinVersion, uint16(tls.VersionTLS13))
+	is.Equal(options.TLSConfig.CipherSuites, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256})
+	is.True(options.TLSConfig.InsecureSkipVerify)
+}
+
+func TestConfig_ConnectionOptions_InlineTLS(t *testing.T) {
+	serverCA := newTestCert(t, "server-ca", nil)
+	clientCA := newTestCert(t, "client-ca", nil)
+	serverCert := newTestCert(t, "server", serverCA)
+	clientCert := newTestCert(t, "client", clientCA)
+
+	srv := startTLSServer(t, server.RANDOM_PORT, serverCert, clientCA, true)
+
+	t.Setenv("TEST_CLIENT_KEY", string(clientCert.keyPEM))
+
+	tests := []struct {
+		name    string
+		tls     TLSConfig
+		wantErr bool
+	}{
+		{
+			name: "inline certificates",
+			tls: TLSConfig{
+				ClientCert:       string(clientCert.certPEM),
+				ClientPrivateKey: "env:TEST_CLIENT_KEY",
+				RootCACert:       string(serverCA.certPEM),
+				ServerName:       "server",
+				HandshakeFirst:   true,
+			},
+		},
+		{
+			name: "wrong server name",
+			tls: TLSConfig{
+				ClientCert:       string(clientCert.certPEM),
+				ClientPrivateKey: "env:TEST_CLIENT_KEY",
+				RootCACert:       string(serverCA.certPEM),
+				ServerName:       "other",
+				HandshakeFirst:   true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "insecure skip verify",
+			tls: TLSConfig{
+				ClientCert:         string(clientCert.certPEM),
+				ClientPrivateKey:   "env:TEST_CLIENT_KEY",
+				InsecureSkipVerify: true,
+				HandshakeFirst:     true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			opts, err := Config{TLS: tt.tls}.ConnectionOptions()
+			is.NoErr(err)
+
+			conn, err := nats.Connect(srv.ClientURL(), append(opts, nats.NoReconnect())...)
+			if tt.wantErr {
+				is.True(err != nil)
+
+				return
+			}
+
+			is.NoErr(err)
+			conn.Close()
+		})
+	}
+}
+
+// freePort returns a TCP port that's free to listen on.
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestConfig_ConnectionOptions_SecureWebSocket(t *testing.T) {
+	is := is.New(t)
+
+	serverCA := newTestCert(t, "server-ca", nil)
+	serverCert := newTestCert(t, "server", serverCA)
+
+	cert, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	is.NoErr(err)
+
+	opts := &server.Options{
+		Host:   "127.0.0.1",
+		Port:   server.RANDOM_PORT,
+		NoLog:  true,
+		NoSigs: true,
+	}
+	opts.Websocket.Host = "127.0.0.1"
+	opts.Websocket.Port = freePort(t)
+	opts.Websocket.TLSConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	srv, err := server.NewServer(opts)
+	is.NoErr(err)
+
+	go srv.Start()
+	is.True(srv.ReadyForConnections(5 * time.Second))
+	t.Cleanup(srv.Shutdown)
+
+	cfg := Config{
+		URLs:    []string{fmt.Sprintf("wss://127.0.0.1:%d", opts.Websocket.Port)},
+		Subject: "websocket",
+		TLS: TLSConfig{
+			RootCACert: string(serverCA.certPEM),
+			ServerName: "server",
+			MinVersion: "1.2",
+		},
+		WebSocket: WebSocketConfig{ProxyPath: "/nats", Compression: true},
+	}
+	is.NoErr(cfg.Validate())
+
+	natsOpts, err := cfg.ConnectionOptions()
+	is.NoErr(err)
+
+	conn, err := nats.Connect(strings.Join(cfg.URLs, ","), append(natsOpts, nats.NoReconnect())...)
+	is.NoErr(err)
+
+	t.Cleanup(conn.Close)
+
+	sub, err := conn.SubscribeSync(cfg.Subject)
+	is.NoErr(err)
+
+	is.NoErr(conn.Publish(cfg.Subject, []byte("hello")))
+
+	msg, err := sub.NextMsg(2 * time.Second)
+	is.NoErr(err)
+	is.Equal(string(msg.Data), "hello")
+}
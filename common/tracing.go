@@ -0,0 +1,93 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Metadata keys carrying the W3C trace context of a record. They have the
+// names of the message headers the trace context is propagated in.
+const (
+	MetadataTraceParent = "traceparent"
+	MetadataTraceState  = "tracestate"
+)
+
+// traceContext propagates the W3C trace context, it drops invalid trace contexts.
+var traceContext = propagation.TraceContext{}
+
+// ExtractTraceContext copies the trace context of a message from its headers to the record metadata.
+// The span of the context isn't propagated, the trace context comes from the headers only.
+func ExtractTraceContext(ctx context.Context, header nats.Header, metadata opencdc.Metadata) {
+	ctx = traceContext.Extract(withoutSpan(ctx), headerCarrier(header))
+	traceContext.Inject(ctx, metadataCarrier(metadata))
+}
+
+// InjectTraceContext copies the trace context of a record from its metadata to the message headers.
+// The span of the context isn't propagated, the trace context comes from the metadata only.
+func InjectTraceContext(ctx context.Context, metadata opencdc.Metadata, header nats.Header) {
+	ctx = traceContext.Extract(withoutSpan(ctx), metadataCarrier(metadata))
+	traceContext.Inject(ctx, headerCarrier(header))
+}
+
+// withoutSpan returns the context without the span it carries, if any.
+func withoutSpan(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(ctx, trace.SpanContext{})
+}
+
+// headerCarrier adapts message headers to a propagation.TextMapCarrier.
+type headerCarrier nats.Header
+
+func (c headerCarrier) Get(key string) string {
+	return HeaderValue(nats.Header(c), key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// metadataCarrier adapts record metadata to a propagation.TextMapCarrier.
+type metadataCarrier opencdc.Metadata
+
+func (c metadataCarrier) Get(key string) string {
+	return c[key]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
@@ -0,0 +1,106 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	testTraceState  = "vendor=value"
+)
+
+func TestExtractTraceContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		header nats.Header
+		want   opencdc.Metadata
+	}{
+		{
+			name:   "trace parent and state",
+			header: nats.Header{"traceparent": {testTraceParent}, "tracestate": {testTraceState}},
+			want:   opencdc.Metadata{MetadataTraceParent: testTraceParent, MetadataTraceState: testTraceState},
+		},
+		{
+			name:   "header names in another case",
+			header: nats.Header{"Traceparent": {testTraceParent}},
+			want:   opencdc.Metadata{MetadataTraceParent: testTraceParent},
+		},
+		{
+			name:   "invalid trace parent",
+			header: nats.Header{"traceparent": {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"}},
+			want:   opencdc.Metadata{},
+		},
+		{
+			name:   "trace state without trace parent",
+			header: nats.Header{"tracestate": {testTraceState}},
+			want:   opencdc.Metadata{},
+		},
+		{
+			name: "no headers",
+			want: opencdc.Metadata{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			metadata := opencdc.Metadata{}
+			ExtractTraceContext(context.Background(), tt.header, metadata)
+			is.Equal(metadata, tt.want)
+		})
+	}
+}
+
+func TestInjectTraceContext(t *testing.T) {
+	is := is.New(t)
+
+	header := nats.Header{}
+	InjectTraceContext(context.Background(), opencdc.Metadata{
+		MetadataTraceParent:        testTraceParent,
+		MetadataTraceState:         testTraceState,
+		opencdc.MetadataCollection: "users",
+	}, header)
+	is.Equal(header, nats.Header{"traceparent": {testTraceParent}, "tracestate": {testTraceState}})
+
+	// records without a trace context don't get trace headers
+	header = nats.Header{}
+	InjectTraceContext(context.Background(), opencdc.Metadata{opencdc.MetadataCollection: "users"}, header)
+	is.Equal(header, nats.Header{})
+
+	// nor do they get the trace context of the caller
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	is.NoErr(err)
+
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	is.NoErr(err)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	header = nats.Header{}
+	InjectTraceContext(ctx, opencdc.Metadata{}, header)
+	is.Equal(header, nats.Header{})
+}
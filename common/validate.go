@@ -0,0 +1,207 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// ErrInvalidURL occurs when a connection URL can't be parsed or has no host.
+	ErrInvalidURL = errors.New("invalid URL")
+	// ErrUnsupportedURLScheme occurs when a connection URL has a scheme the connector doesn't support.
+	ErrUnsupportedURLScheme = errors.New("unsupported URL scheme")
+	// ErrMixedWebSocketURLs occurs when WebSocket and non-WebSocket URLs are configured together.
+	ErrMixedWebSocketURLs = errors.New("ws:// and wss:// urls can't be mixed with nats:// and tls:// urls")
+	// ErrWebSocketSettingsWithoutWebSocket occurs when WebSocket settings are configured without WebSocket URLs.
+	ErrWebSocketSettingsWithoutWebSocket = errors.New("websocket settings require ws:// or wss:// urls")
+	// ErrHandshakeFirstWebSocket occurs when tls.handshakeFirst is configured with WebSocket URLs.
+	ErrHandshakeFirstWebSocket = errors.New("tls.handshakeFirst isn't supported with ws:// and wss:// urls")
+	// ErrURLCredentialsConflict occurs when a URL contains credentials, but another authentication method is set.
+	ErrURLCredentialsConflict = errors.New("urls must not contain credentials when another authentication method is set")
+	// ErrNegativeDuration occurs when a connection tuning duration is negative.
+	ErrNegativeDuration = errors.New("must not be negative")
+	// ErrSubjectWhitespace occurs when a subject contains whitespace.
+	ErrSubjectWhitespace = errors.New("subject must not contain whitespace")
+	// ErrSubjectEmptyToken occurs when a subject is empty, or starts, ends or contains consecutive dots.
+	ErrSubjectEmptyToken = errors.New("subject must not contain empty tokens")
+	// ErrSubjectInvalidWildcard occurs when a wildcard isn't a whole token, or ">" isn't the last token.
+	ErrSubjectInvalidWildcard = errors.New(`wildcards must be whole tokens, and ">" must be the last token`)
+	// ErrSubjectWildcardNotAllowed occurs when a subject records are published to contains a wildcard.
+	ErrSubjectWildcardNotAllowed = errors.New("subject to publish to must not contain wildcards")
+	// ErrIncompleteTLSKeyPair occurs when only one of a TLS client certificate and its private key is set.
+	ErrIncompleteTLSKeyPair = errors.New("tls client certificate and private key must be set together")
+	// ErrInvalidTLSKeyPair occurs when a TLS client certificate and private key can't be loaded or don't match.
+	ErrInvalidTLSKeyPair = errors.New("invalid tls client certificate and private key")
+)
+
+// urlSchemes are the URL schemes the connector can connect to.
+var urlSchemes = []string{"nats", "tls", "ws", "wss"}
+
+// isWebSocketScheme reports whether the URL scheme connects over WebSocket.
+func isWebSocketScheme(scheme string) bool {
+	return scheme == "ws" || scheme == "wss"
+}
+
+// ValidateSubject checks that the subject is a valid subject to subscribe to,
+// wildcards are allowed as whole tokens.
+func ValidateSubject(subject string) error {
+	if strings.IndexFunc(subject, unicode.IsSpace) != -1 {
+		return fmt.Errorf("%q: %w", subject, ErrSubjectWhitespace)
+	}
+
+	tokens := strings.Split(subject, ".")
+	for i, token := range tokens {
+		switch {
+		case token == "":
+			return fmt.Errorf("%q: %w", subject, ErrSubjectEmptyToken)
+		case token == ">" && i != len(tokens)-1:
+			return fmt.Errorf("%q: %w", subject, ErrSubjectInvalidWildcard)
+		case token != "*" && token != ">" && strings.ContainsAny(token, "*>"):
+			return fmt.Errorf("%q: %w", subject, ErrSubjectInvalidWildcard)
+		}
+	}
+
+	return nil
+}
+
+// ValidatePublishSubject checks that the subject is a valid subject to publish to.
+func ValidatePublishSubject(subject string) error {
+	if err := ValidateSubject(subject); err != nil {
+		return err
+	}
+
+	if strings.ContainsAny(subject, "*>") {
+		return fmt.Errorf("%q: %w", subject, ErrSubjectWildcardNotAllowed)
+	}
+
+	return nil
+}
+
+// validateURLs checks that the connection URLs can be connected to.
+// The returned errors contain the URLs with redacted credentials.
+func (c Config) validateURLs() error {
+	webSocketURLs := 0
+	for _, rawURL := range c.URLs {
+		rawURL = normalizeURL(rawURL)
+
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("%q: %w", RedactURL(rawURL), ErrInvalidURL)
+		}
+
+		if !slices.Contains(urlSchemes, u.Scheme) {
+			return fmt.Errorf("%q: %w, must be one of %s", RedactURL(rawURL), ErrUnsupportedURLScheme, strings.Join(urlSchemes, ", "))
+		}
+
+		if u.User != nil && c.authMethods() > 0 {
+			return fmt.Errorf("%q: %w", RedactURL(rawURL), ErrURLCredentialsConflict)
+		}
+
+		if isWebSocketScheme(u.Scheme) {
+			webSocketURLs++
+		}
+	}
+
+	switch {
+	case webSocketURLs > 0 && webSocketURLs < len(c.URLs):
+		return ErrMixedWebSocketURLs
+	case webSocketURLs == 0 && (c.WebSocket.ProxyPath != "" || c.WebSocket.Compression):
+		return ErrWebSocketSettingsWithoutWebSocket
+	case webSocketURLs > 0 && c.TLS.HandshakeFirst:
+		return ErrHandshakeFirstWebSocket
+	}
+
+	return nil
+}
+
+// validateTuning checks the connection tuning values.
+func (c Config) validateTuning() error {
+	for name, d := range map[string]time.Duration{
+		"reconnectWait":      c.ReconnectWait,
+		"reconnectJitter":    c.ReconnectJitter,
+		"reconnectJitterTLS": c.ReconnectJitterTLS,
+		"connectTimeout":     c.ConnectTimeout,
+		"pingInterval":       c.PingInterval,
+		"flusherTimeout":     c.FlusherTimeout,
+		"startupWait":        c.StartupWait,
+	} {
+		if d < 0 {
+			return fmt.Errorf("%s: %w", name, ErrNegativeDuration)
+		}
+	}
+
+	if c.InboxPrefix != "" {
+		if err := ValidatePublishSubject(c.InboxPrefix); err != nil {
+			return fmt.Errorf("inboxPrefix: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// authMethods returns the number of configured authentication methods.
+func (c Config) authMethods() int {
+	methods := 0
+	for _, v := range []string{c.Username, c.Token, c.NKeyPath, c.CredentialsFilePath, c.NKeySeed} {
+		if v != "" {
+			methods++
+		}
+	}
+
+	return methods
+}
+
+// validateKeyPair checks that the TLS client certificate and private key are set together and match.
+func (c TLSConfig) validateKeyPair() error {
+	if (c.ClientCertPath == "") != (c.ClientPrivateKeyPath == "") {
+		return fmt.Errorf("tls.clientCertPath and tls.clientPrivateKeyPath: %w", ErrIncompleteTLSKeyPair)
+	}
+
+	if (c.ClientCert == "") != (c.ClientPrivateKey == "") {
+		return fmt.Errorf("tls.clientCert and tls.clientPrivateKey: %w", ErrIncompleteTLSKeyPair)
+	}
+
+	if c.ClientCertPath != "" {
+		if _, err := tls.LoadX509KeyPair(c.ClientCertPath, c.ClientPrivateKeyPath); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidTLSKeyPair, err)
+		}
+	}
+
+	if c.ClientCert != "" {
+		certPEM, err := resolveValue(c.ClientCert)
+		if err != nil {
+			return fmt.Errorf("resolve client certificate: %w", err)
+		}
+
+		keyPEM, err := resolveValue(c.ClientPrivateKey)
+		if err != nil {
+			return fmt.Errorf("resolve client private key: %w", err)
+		}
+
+		if _, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM)); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidTLSKeyPair, err)
+		}
+	}
+
+	return nil
+}
@@ -17,9 +17,245 @@
 package destination
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/destination/pubsub"
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+const (
+	// DeletePolicyPublish publishes a tombstone message for deleted records.
+	DeletePolicyPublish = "publish"
+	// DeletePolicySkip drops deleted records without publishing them.
+	DeletePolicySkip = "skip"
+)
+
+var (
+	// ErrRouteSubjectRequired occurs when a route has no subject.
+	ErrRouteSubjectRequired = errors.New("route subject is required")
+	// ErrInvalidRouteOperation occurs when a route operation isn't a known operation.
+	ErrInvalidRouteOperation = errors.New("route operation must be one of create, update, delete, snapshot")
+	// ErrOverflowSubjectRequired occurs when oversizePolicy is "overflow", but there's no overflowSubject.
+	ErrOverflowSubjectRequired = errors.New(`overflowSubject is required when oversizePolicy is "overflow"`)
+	// ErrKeyIDRequired occurs when encryption is enabled, but there's no encryption.keyId.
+	ErrKeyIDRequired = errors.New(`encryption.keyId is required when encryption.keyProvider isn't "none"`)
+	// ErrDropUnmatchedWithoutRoutes occurs when dropUnmatched is set, but there are no routes.
+	ErrDropUnmatchedWithoutRoutes = errors.New("dropUnmatched requires at least one route")
+	// ErrNonPositiveWriteTimeout occurs when writeTimeout isn't greater than 0.
+	ErrNonPositiveWriteTimeout = errors.New("writeTimeout must be greater than 0")
 )
 
 type Config struct {
 	common.Config
+
+	// Defines how records with the delete operation are handled.
+	// "publish" publishes a tombstone message, "skip" drops the record.
+	DeletePolicy string `json:"deletePolicy" default:"publish" validate:"inclusion=publish|skip"`
+	// The payload of a tombstone message published for a deleted record.
+	// "empty" publishes an empty payload, "key" publishes the record key,
+	// "record" publishes the whole record serialized with sdk.record.format.
+	TombstoneFormat string `json:"tombstoneFormat" default:"empty" validate:"inclusion=empty|key|record"`
+	// If true, the record operation is appended to the subject as an additional token,
+	// e.g. "foo.create", "foo.update" or "foo.delete".
+	OperationSubjectSuffix bool `json:"operationSubjectSuffix" default:"false"`
+	// The name of a message header that carries the record operation,
+	// e.g. "opencdc.operation". If empty, the header is not set.
+	OperationHeader string `json:"operationHeader"`
+	// A JSON array of routes that select the subject a record is published to
+	// based on its operation, collection and metadata, e.g.
+	// [{"operations":["delete"],"collection":"users","subject":"users.deletes"}].
+	// Routes are evaluated in order and the first matching route wins.
+	// Records that match no route are published to the subject.
+	Routes string `json:"routes"`
+	// If true, records that match no route are dropped instead of being published
+	// to the subject.
+	DropUnmatched bool `json:"dropUnmatched" default:"false"`
+	// Defines how records exceeding the server's max payload are handled.
+	// "fail" returns an error, "skip" drops the record and logs a warning,
+	// "split" publishes the payload in chunks carrying reassembly headers,
+	// "overflow" publishes the record key to the overflowSubject instead.
+	OversizePolicy string `json:"oversizePolicy" default:"fail" validate:"inclusion=fail|skip|split|overflow"`
+	// The name of a subject oversized records are diverted to if oversizePolicy is "overflow".
+	OverflowSubject string `json:"overflowSubject"`
+	// Defines how records written while the connection is down are handled.
+	// "buffer" publishes them to the reconnect buffer, they are sent once reconnected,
+	// "fail" returns an error, so the records can be written again later.
+	DisconnectPolicy string `json:"disconnectPolicy" default:"buffer" validate:"inclusion=buffer|fail"`
+	// The maximum time to publish a batch of records and wait for the server to
+	// confirm it received them. The batch fails if the time is exceeded.
+	WriteTimeout time.Duration `json:"writeTimeout" default:"30s"`
+
+	Compression CompressionConfig    `json:"compression"`
+	Encryption  EncryptionConfig     `json:"encryption"`
+	Metrics     common.MetricsConfig `json:"metrics"`
+	CloudEvents CloudEventsConfig    `json:"cloudEvents"`
+	Schema      SchemaConfig         `json:"schema"`
+}
+
+// CompressionConfig holds the configuration of compressing message payloads.
+type CompressionConfig struct {
+	// The codec used to compress message payloads, "none" disables compression.
+	// Compressed messages carry the codec name in the Content-Encoding header.
+	Codec string `json:"codec" default:"none" validate:"inclusion=none|gzip|zstd|snappy"`
+	// Payloads smaller than this number of bytes are sent uncompressed.
+	MinSize int `json:"minSize" default:"1024" validate:"gt=-1"`
+}
+
+// CloudEventsConfig holds the configuration of publishing records as CloudEvents.
+type CloudEventsConfig struct {
+	// Defines whether and how records are published as CloudEvents. "none" publishes
+	// plain messages, "binary" publishes the event attributes in ce- headers and the
+	// record payload as the event data, "structured" publishes a JSON envelope with
+	// the application/cloudevents+json content type. Attributes are taken from the
+	// record metadata prefixed with "cloudevents.", or derived from the record.
+	Mode string `json:"mode" default:"none" validate:"inclusion=none|binary|structured"`
+	// The source attribute of events whose record has no cloudevents.source metadata.
+	Source string `json:"source" default:"conduit"`
+	// The prefix of the type attribute of events whose record has no cloudevents.type
+	// metadata, followed by the record operation, e.g. "io.conduit.record.create".
+	TypePrefix string `json:"typePrefix" default:"io.conduit.record"`
+}
+
+// SchemaConfig holds the configuration of encoding structured payloads with a schema.
+type SchemaConfig struct {
+	// The format structured payloads are encoded in. "none" publishes JSON, "avro"
+	// encodes them with an Avro schema from Conduit's schema service and sets the
+	// Conduit-Schema-Subject, Conduit-Schema-Version and Conduit-Schema-Id headers.
+	// The schema the record metadata refers to is used, if any, otherwise a schema
+	// is extracted from the payload and registered.
+	Format string `json:"format" default:"none" validate:"inclusion=none|avro"`
+	// The subject schemas extracted from payloads are registered under.
+	// If empty, it's the subject the message is published to followed by ".payload".
+	Subject string `json:"subject"`
+}
+
+// RouteConfig holds the conditions of a single route and its target subject.
+type RouteConfig struct {
+	// Operations (create, update, delete, snapshot) the route matches.
+	// If empty, the route matches any operation.
+	Operations []string `json:"operations"`
+	// The collection (opencdc.collection metadata) the route matches.
+	// If empty, the route matches any collection.
+	Collection string `json:"collection"`
+	// Metadata entries a record must contain to match the route.
+	Metadata map[string]string `json:"metadata"`
+	// The name of a subject matching records are published to.
+	Subject string `json:"subject"`
+}
+
+// EncryptionConfig holds the configuration of envelope-encrypting message payloads.
+type EncryptionConfig struct {
+	common.EncryptionConfig
+
+	// The id of the key used to encrypt messages. To rotate the key, change the id
+	// and keep the old key available to consumers until they processed all the
+	// messages encrypted with it.
+	KeyID string `json:"keyId"`
+}
+
+// Validate checks the config values that can't be expressed as parameter validations.
+func (c Config) Validate() error {
+	if err := c.Config.Validate(); err != nil {
+		return fmt.Errorf("validate connection config: %w", err)
+	}
+
+	if err := common.ValidatePublishSubject(c.Subject); err != nil {
+		return fmt.Errorf("validate subject: %w", err)
+	}
+
+	routes, err := c.ParseRoutes()
+	if err != nil {
+		return err
+	}
+
+	if c.WriteTimeout <= 0 {
+		return ErrNonPositiveWriteTimeout
+	}
+
+	if c.DropUnmatched && len(routes) == 0 {
+		return ErrDropUnmatchedWithoutRoutes
+	}
+
+	if c.OversizePolicy == string(pubsub.OversizePolicyOverflow) && c.OverflowSubject == "" {
+		return ErrOverflowSubjectRequired
+	}
+
+	if c.OverflowSubject != "" {
+		if err := common.ValidatePublishSubject(c.OverflowSubject); err != nil {
+			return fmt.Errorf("validate overflowSubject: %w", err)
+		}
+	}
+
+	if err := c.Encryption.Validate(); err != nil {
+		return fmt.Errorf("validate encryption: %w", err)
+	}
+
+	if c.Encryption.KeyProvider != common.KeyProviderNone && c.Encryption.KeyID == "" {
+		return ErrKeyIDRequired
+	}
+
+	if err := c.Metrics.Validate(); err != nil {
+		return fmt.Errorf("validate metrics: %w", err)
+	}
+
+	return nil
+}
+
+// ParseRoutes converts the configured routes into pubsub.Routes, keeping their order.
+func (c Config) ParseRoutes() ([]pubsub.Route, error) {
+	if c.Routes == "" {
+		return nil, nil
+	}
+
+	var routeConfigs []RouteConfig
+	if err := json.Unmarshal([]byte(c.Routes), &routeConfigs); err != nil {
+		return nil, fmt.Errorf("unmarshal routes: %w", err)
+	}
+
+	routes := make([]pubsub.Route, 0, len(routeConfigs))
+	for i, routeConfig := range routeConfigs {
+		route, err := routeConfig.parse()
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %w", i, err)
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+// parse converts the RouteConfig into a pubsub.Route.
+func (rc RouteConfig) parse() (pubsub.Route, error) {
+	if rc.Subject == "" {
+		return pubsub.Route{}, ErrRouteSubjectRequired
+	}
+
+	if err := common.ValidatePublishSubject(rc.Subject); err != nil {
+		return pubsub.Route{}, fmt.Errorf("validate subject: %w", err)
+	}
+
+	route := pubsub.Route{
+		Operations: make([]opencdc.Operation, 0, len(rc.Operations)),
+		Collection: rc.Collection,
+		Metadata:   rc.Metadata,
+		Subject:    rc.Subject,
+	}
+
+	for _, raw := range rc.Operations {
+		var operation opencdc.Operation
+		err := operation.UnmarshalText([]byte(strings.TrimSpace(raw)))
+		if err != nil || operation < opencdc.OperationCreate || operation > opencdc.OperationSnapshot {
+			return pubsub.Route{}, fmt.Errorf("%q: %w", raw, ErrInvalidRouteOperation)
+		}
+
+		route.Operations = append(route.Operations, operation)
+	}
+
+	return route, nil
 }
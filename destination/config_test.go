@@ -0,0 +1,227 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/destination/pubsub"
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/google/go-cmp/cmp"
+	"github.com/matryer/is"
+)
+
+func TestConfig_ParseRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     map[string]string
+		want    []pubsub.Route
+		wantErr error
+	}{
+		{
+			name: "success, no routes",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:1222",
+				ConfigSubject: "foo",
+			},
+			want:    nil,
+			wantErr: nil,
+		},
+		{
+			name: "success, routes keep their order",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:1222",
+				ConfigSubject: "foo",
+				ConfigRoutes: `[
+					{"operations":["create","update"],"metadata":{"source":"pg"},"subject":"orders.changes"},
+					{"operations":["delete"],"collection":"users","subject":"users.deletes"},
+					{"subject":"everything"}
+				]`,
+			},
+			want: []pubsub.Route{
+				{
+					Operations: []opencdc.Operation{opencdc.OperationCreate, opencdc.OperationUpdate},
+					Metadata:   map[string]string{"source": "pg"},
+					Subject:    "orders.changes",
+				},
+				{
+					Operations: []opencdc.Operation{opencdc.OperationDelete},
+					Collection: "users",
+					Subject:    "users.deletes",
+				},
+				{
+					Operations: []opencdc.Operation{},
+					Subject:    "everything",
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "fail, missing route subject",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:1222",
+				ConfigSubject: "foo",
+				ConfigRoutes:  `[{"operations":["create"]}]`,
+			},
+			wantErr: ErrRouteSubjectRequired,
+		},
+		{
+			name: "fail, unknown route operation",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:1222",
+				ConfigSubject: "foo",
+				ConfigRoutes:  `[{"operations":["insert"],"subject":"bar"}]`,
+			},
+			wantErr: ErrInvalidRouteOperation,
+		},
+		{
+			name: "fail, route subject with wildcard",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:1222",
+				ConfigSubject: "foo",
+				ConfigRoutes:  `[{"collection":"users","subject":"users.*"}]`,
+			},
+			wantErr: common.ErrSubjectWildcardNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			var cfg Config
+			err := sdk.Util.ParseConfig(context.Background(), tt.cfg, &cfg, NewDestination().Parameters())
+			is.NoErr(err)
+
+			got, err := cfg.ParseRoutes()
+			if tt.wantErr != nil {
+				is.True(errors.Is(err, tt.wantErr))
+
+				return
+			}
+			is.NoErr(err)
+
+			is.Equal("", cmp.Diff(tt.want, got))
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     map[string]string
+		wantErr error
+	}{
+		{
+			name: "success",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:1222",
+				ConfigSubject: "orders.created",
+			},
+		},
+		{
+			name: "fail, subject with single token wildcard",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:1222",
+				ConfigSubject: "orders.*",
+			},
+			wantErr: common.ErrSubjectWildcardNotAllowed,
+		},
+		{
+			name: "fail, subject with full wildcard",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:1222",
+				ConfigSubject: "orders.>",
+			},
+			wantErr: common.ErrSubjectWildcardNotAllowed,
+		},
+		{
+			name: "fail, subject with empty token",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:1222",
+				ConfigSubject: "orders..created",
+			},
+			wantErr: common.ErrSubjectEmptyToken,
+		},
+		{
+			name: "fail, overflow subject with wildcard",
+			cfg: map[string]string{
+				ConfigUrls:            "nats://127.0.0.1:1222",
+				ConfigSubject:         "orders",
+				ConfigOversizePolicy:  "overflow",
+				ConfigOverflowSubject: "orders.oversized.>",
+			},
+			wantErr: common.ErrSubjectWildcardNotAllowed,
+		},
+		{
+			name: "fail, overflow subject with whitespace",
+			cfg: map[string]string{
+				ConfigUrls:            "nats://127.0.0.1:1222",
+				ConfigSubject:         "orders",
+				ConfigOversizePolicy:  "overflow",
+				ConfigOverflowSubject: "orders oversized",
+			},
+			wantErr: common.ErrSubjectWhitespace,
+		},
+		{
+			name: "fail, zero write timeout",
+			cfg: map[string]string{
+				ConfigUrls:         "nats://127.0.0.1:1222",
+				ConfigSubject:      "orders",
+				ConfigWriteTimeout: "0s",
+			},
+			wantErr: ErrNonPositiveWriteTimeout,
+		},
+		{
+			name: "fail, unsupported url scheme",
+			cfg: map[string]string{
+				ConfigUrls:    "https://127.0.0.1:1222",
+				ConfigSubject: "orders",
+			},
+			wantErr: common.ErrUnsupportedURLScheme,
+		},
+		{
+			name: "fail, lone tls client certificate",
+			cfg: map[string]string{
+				ConfigUrls:              "nats://127.0.0.1:1222",
+				ConfigSubject:           "orders",
+				ConfigTlsClientCertPath: "./cert.pem",
+			},
+			wantErr: common.ErrIncompleteTLSKeyPair,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			var cfg Config
+			err := sdk.Util.ParseConfig(context.Background(), tt.cfg, &cfg, NewDestination().Parameters())
+			is.NoErr(err)
+
+			err = cfg.Validate()
+			if tt.wantErr != nil {
+				is.True(errors.Is(err, tt.wantErr))
+
+				return
+			}
+			is.NoErr(err)
+		})
+	}
+}
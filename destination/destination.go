@@ -17,18 +17,20 @@ package destination
 import (
 	"context"
 	"fmt"
-	"strings"
+	"time"
 
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
 	"github.com/conduitio-labs/conduit-connector-nats-pubsub/destination/pubsub"
 	"github.com/conduitio/conduit-commons/config"
 	"github.com/conduitio/conduit-commons/opencdc"
 	sdk "github.com/conduitio/conduit-connector-sdk"
-	"github.com/nats-io/nats.go"
 )
 
 // Writer defines a writer interface needed for the Destination.
 type Writer interface {
-	Write(record opencdc.Record) error
+	Write(ctx context.Context, record opencdc.Record) error
+	Flush(ctx context.Context) error
+	BufferStats() pubsub.BufferStats
 	Close() error
 }
 
@@ -36,8 +38,9 @@ type Writer interface {
 type Destination struct {
 	sdk.UnimplementedDestination
 
-	config Config
-	writer Writer
+	config      Config
+	writer      Writer
+	stopMetrics func()
 }
 
 // NewDestination creates new instance of the Destination.
@@ -57,54 +60,162 @@ func (d *Destination) Configure(ctx context.Context, cfg config.Config) error {
 		return err //nolint:wrapcheck // we don't need to wrap the error here
 	}
 
+	if err := d.config.Validate(); err != nil {
+		return fmt.Errorf("validate config: %w", err)
+	}
+
 	connName := d.config.GetConnectionName()
-	sdk.Logger(ctx).Info().Str("connectionName", connName).Msg("configured connection name")
+	sdk.Logger(ctx).Info().
+		Str("connectionName", connName).
+		Strs("urls", d.config.RedactedURLs()).
+		Msg("configured connection name")
 
 	return nil
 }
 
 // Open makes sure everything is prepared to receive records.
-func (d *Destination) Open(context.Context) error {
-	opts, err := d.config.ConnectionOptions()
+func (d *Destination) Open(ctx context.Context) error {
+	routes, err := d.config.ParseRoutes()
 	if err != nil {
-		return fmt.Errorf("get connection options: %w", err)
+		return fmt.Errorf("parse routes: %w", err)
 	}
 
-	conn, err := nats.Connect(strings.Join(d.config.URLs, ","), opts...)
+	keyProvider := d.config.Encryption.NewKeyProvider()
+	if err := d.checkEncryptionKey(keyProvider); err != nil {
+		return err
+	}
+
+	d.stopMetrics, err = d.config.Metrics.ServeMetrics()
+	if err != nil {
+		return fmt.Errorf("serve metrics: %w", err)
+	}
+
+	bufferTracker := pubsub.NewBufferTracker(sdk.Logger(ctx))
+
+	conn, err := d.config.Connect(ctx, bufferTracker.Options()...)
 	if err != nil {
 		return fmt.Errorf("connect to NATS: %w", err)
 	}
 
 	d.writer, err = pubsub.NewWriter(pubsub.WriterParams{
-		Conn:    conn,
-		Subject: d.config.Subject,
+		Conn:                   conn,
+		Subject:                d.config.Subject,
+		SkipDeletes:            d.config.DeletePolicy == DeletePolicySkip,
+		TombstoneFormat:        pubsub.TombstoneFormat(d.config.TombstoneFormat),
+		OperationSubjectSuffix: d.config.OperationSubjectSuffix,
+		OperationHeader:        d.config.OperationHeader,
+		Routes:                 routes,
+		DropUnmatched:          d.config.DropUnmatched,
+		OversizePolicy:         pubsub.OversizePolicy(d.config.OversizePolicy),
+		OverflowSubject:        d.config.OverflowSubject,
+		Compression:            d.config.Compression.Codec,
+		CompressionMinSize:     d.config.Compression.MinSize,
+		KeyProvider:            keyProvider,
+		KeyID:                  d.config.Encryption.KeyID,
+		DisconnectPolicy:       pubsub.DisconnectPolicy(d.config.DisconnectPolicy),
+		BufferTracker:          bufferTracker,
+		CloudEventsMode:        pubsub.CloudEventsMode(d.config.CloudEvents.Mode),
+		CloudEventsSource:      d.config.CloudEvents.Source,
+		CloudEventsTypePrefix:  d.config.CloudEvents.TypePrefix,
+		SchemaFormat:           pubsub.SchemaFormat(d.config.Schema.Format),
+		SchemaSubject:          d.config.Schema.Subject,
+		Logger:                 sdk.Logger(ctx),
 	})
 	if err != nil {
 		return fmt.Errorf("init pubsub writer: %w", err)
 	}
 
+	logConnection(ctx, conn)
+
 	return nil
 }
 
-// Write writes a record into a Destination.
-func (d *Destination) Write(_ context.Context, records []opencdc.Record) (int, error) {
+// logConnection logs the server the destination is connected to, or that it isn't connected yet.
+func logConnection(ctx context.Context, conn *common.Conn) {
+	if !conn.IsConnected() {
+		sdk.Logger(ctx).Warn().Msg("no NATS server reachable yet, connecting in the background")
+
+		return
+	}
+
+	sdk.Logger(ctx).Info().
+		Str("url", common.RedactURL(conn.ConnectedUrl())).
+		Int64("maxPayload", conn.MaxPayload()).
+		Msg("connected to NATS")
+}
+
+// checkEncryptionKey makes sure the encryption key is available before any record is written.
+func (d *Destination) checkEncryptionKey(keyProvider common.KeyProvider) error {
+	if keyProvider == nil {
+		return nil
+	}
+
+	if _, err := keyProvider.Key(d.config.Encryption.KeyID); err != nil {
+		return fmt.Errorf("load encryption key: %w", err)
+	}
+
+	return nil
+}
+
+// Write writes records into a Destination and waits for the server to confirm them,
+// all within writeTimeout. If the confirmation fails, none of the records count as written.
+// If a record can't be written, the records before it are confirmed, unless the context
+// is already done, in which case none of the records count as written.
+func (d *Destination) Write(ctx context.Context, records []opencdc.Record) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.WriteTimeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() { common.MetricPublishDuration.Observe(time.Since(start).Seconds()) }()
+
 	for i, record := range records {
-		err := d.writer.Write(record)
+		err := d.writer.Write(ctx, record)
 		if err != nil {
-			return i, fmt.Errorf("write: %w", err)
+			return d.confirmWritten(ctx, i), fmt.Errorf("write: %w", err)
 		}
 	}
 
+	if err := d.writer.Flush(ctx); err != nil {
+		return 0, fmt.Errorf("confirm %d records: %w", len(records), err)
+	}
+
 	return len(records), nil
 }
 
+// confirmWritten waits for the server to confirm the n records written before a record
+// failed, and returns the number of confirmed records.
+func (d *Destination) confirmWritten(ctx context.Context, n int) int {
+	if n == 0 || ctx.Err() != nil {
+		return 0
+	}
+
+	if err := d.writer.Flush(ctx); err != nil {
+		sdk.Logger(ctx).Warn().Err(err).Int("records", n).Msg("failed to confirm the records written before the failure")
+
+		return 0
+	}
+
+	return n
+}
+
 // Teardown gracefully closes connections.
-func (d *Destination) Teardown(context.Context) error {
+func (d *Destination) Teardown(ctx context.Context) error {
 	if d.writer != nil {
 		err := d.writer.Close()
 		if err != nil {
 			return fmt.Errorf("failed to close writer: %w", err)
 		}
+
+		stats := d.writer.BufferStats()
+		sdk.Logger(ctx).Info().
+			Int64("buffered", stats.Confirmed+stats.Lost).
+			Int64("confirmed", stats.Confirmed).
+			Int64("lost", stats.Lost).
+			Msg("messages published while disconnected")
+	}
+
+	if d.stopMetrics != nil {
+		d.stopMetrics()
 	}
 
 	return nil
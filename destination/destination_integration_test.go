@@ -15,11 +15,17 @@
 package destination
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/destination/pubsub"
 	"github.com/conduitio-labs/conduit-connector-nats-pubsub/test"
 	"github.com/conduitio/conduit-commons/opencdc"
 	"github.com/matryer/is"
@@ -167,3 +173,436 @@ func TestDestination_WriteManyMessages(t *testing.T) {
 	err = destination.Teardown(context.Background())
 	is.NoErr(err)
 }
+
+func TestDestination_WriteDelete(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         map[string]string
+		subject     string
+		wantMessage bool
+		wantData    []byte
+		wantHeader  string
+	}{
+		{
+			name: "publish empty tombstone with operation header",
+			cfg: map[string]string{
+				ConfigOperationHeader: "opencdc.operation",
+			},
+			wantMessage: true,
+			wantData:    []byte{},
+			wantHeader:  "delete",
+		},
+		{
+			name: "publish key tombstone",
+			cfg: map[string]string{
+				ConfigTombstoneFormat: "key",
+				ConfigOperationHeader: "opencdc.operation",
+			},
+			wantMessage: true,
+			wantData:    []byte("key-1"),
+			wantHeader:  "delete",
+		},
+		{
+			name: "publish to operation subject without header",
+			cfg: map[string]string{
+				ConfigOperationSubjectSuffix: "true",
+			},
+			subject:     ".delete",
+			wantMessage: true,
+			wantData:    []byte{},
+			wantHeader:  "",
+		},
+		{
+			name: "skip deletes",
+			cfg: map[string]string{
+				ConfigDeletePolicy: "skip",
+			},
+			wantMessage: false,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			subject := fmt.Sprintf("foo_destination_write_delete_%d", i)
+
+			testConn, err := nats.Connect(test.TestURL)
+			is.NoErr(err)
+
+			t.Cleanup(func() {
+				testConn.Flush()
+				testConn.Close()
+			})
+
+			subscription, err := testConn.SubscribeSync(subject + tt.subject)
+			is.NoErr(err)
+
+			cfg := map[string]string{
+				ConfigUrls:    test.TestURL,
+				ConfigSubject: subject,
+			}
+			for k, v := range tt.cfg {
+				cfg[k] = v
+			}
+
+			destination := NewDestination()
+
+			err = destination.Configure(context.Background(), cfg)
+			is.NoErr(err)
+
+			err = destination.Open(context.Background())
+			is.NoErr(err)
+
+			t.Cleanup(func() {
+				is.NoErr(destination.Teardown(context.Background()))
+			})
+
+			count, err := destination.Write(context.Background(), []opencdc.Record{
+				{
+					Operation: opencdc.OperationDelete,
+					Key:       opencdc.RawData("key-1"),
+					Payload: opencdc.Change{
+						Before: opencdc.RawData("hello"),
+					},
+				},
+			})
+			is.NoErr(err)
+			is.Equal(count, 1)
+
+			msg, err := subscription.NextMsg(time.Millisecond * 500)
+			if !tt.wantMessage {
+				is.True(errors.Is(err, nats.ErrTimeout))
+
+				return
+			}
+			is.NoErr(err)
+
+			is.Equal(msg.Data, tt.wantData)
+			is.Equal(msg.Header.Get("opencdc.operation"), tt.wantHeader)
+		})
+	}
+}
+
+func TestDestination_WriteRoutes(t *testing.T) {
+	is := is.New(t)
+
+	subject := "foo_destination_write_routes"
+
+	testConn, err := nats.Connect(test.TestURL)
+	is.NoErr(err)
+
+	t.Cleanup(func() {
+		testConn.Flush()
+		testConn.Close()
+	})
+
+	subscription, err := testConn.SubscribeSync(subject + ".>")
+	is.NoErr(err)
+
+	destination := NewDestination()
+
+	err = destination.Configure(context.Background(), map[string]string{
+		ConfigUrls:    test.TestURL,
+		ConfigSubject: subject + ".fallback",
+		ConfigRoutes: fmt.Sprintf(
+			`[{"operations":["delete"],"subject":"%[1]s.deletes"},{"collection":"users","subject":"%[1]s.users"}]`,
+			subject,
+		),
+	})
+	is.NoErr(err)
+
+	err = destination.Open(context.Background())
+	is.NoErr(err)
+
+	t.Cleanup(func() {
+		is.NoErr(destination.Teardown(context.Background()))
+	})
+
+	records := []opencdc.Record{
+		{
+			Operation: opencdc.OperationCreate,
+			Metadata:  opencdc.Metadata{opencdc.MetadataCollection: "users"},
+			Payload:   opencdc.Change{After: opencdc.RawData("user")},
+		},
+		{
+			Operation: opencdc.OperationDelete,
+			Metadata:  opencdc.Metadata{opencdc.MetadataCollection: "users"},
+		},
+		{
+			Operation: opencdc.OperationCreate,
+			Metadata:  opencdc.Metadata{opencdc.MetadataCollection: "orders"},
+			Payload:   opencdc.Change{After: opencdc.RawData("order")},
+		},
+	}
+
+	count, err := destination.Write(context.Background(), records)
+	is.NoErr(err)
+	is.Equal(count, len(records))
+
+	for _, want := range []string{subject + ".users", subject + ".deletes", subject + ".fallback"} {
+		msg, err := subscription.NextMsg(time.Second * 2)
+		is.NoErr(err)
+		is.Equal(msg.Subject, want)
+	}
+}
+
+func TestDestination_WriteOversized(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        map[string]string
+		wantErr    error
+		wantChunks int
+		wantData   []byte
+	}{
+		{
+			name:    "fail",
+			cfg:     map[string]string{},
+			wantErr: pubsub.ErrMessageTooLarge,
+		},
+		{
+			name: "skip",
+			cfg: map[string]string{
+				ConfigOversizePolicy: "skip",
+			},
+			wantChunks: 0,
+		},
+		{
+			name: "split",
+			cfg: map[string]string{
+				ConfigOversizePolicy: "split",
+			},
+			wantChunks: 3,
+		},
+		{
+			name: "overflow",
+			cfg: map[string]string{
+				ConfigOversizePolicy:  "overflow",
+				ConfigOverflowSubject: "foo_destination_write_oversized_overflow.big",
+			},
+			wantChunks: 1,
+			wantData:   []byte("key-1"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			subject := "foo_destination_write_oversized_" + tt.name
+
+			testConn, err := nats.Connect(test.TestURL)
+			is.NoErr(err)
+
+			t.Cleanup(func() {
+				testConn.Flush()
+				testConn.Close()
+			})
+
+			subscription, err := testConn.SubscribeSync(subject + ".>")
+			is.NoErr(err)
+
+			cfg := map[string]string{
+				ConfigUrls:    test.TestURL,
+				ConfigSubject: subject + ".records",
+			}
+			for k, v := range tt.cfg {
+				cfg[k] = v
+			}
+
+			destination := NewDestination()
+
+			err = destination.Configure(context.Background(), cfg)
+			is.NoErr(err)
+
+			err = destination.Open(context.Background())
+			is.NoErr(err)
+
+			t.Cleanup(func() {
+				is.NoErr(destination.Teardown(context.Background()))
+			})
+
+			payload := bytes.Repeat([]byte("a"), int(testConn.MaxPayload())*5/2)
+
+			count, err := destination.Write(context.Background(), []opencdc.Record{
+				{
+					Operation: opencdc.OperationCreate,
+					Key:       opencdc.RawData("key-1"),
+					Payload:   opencdc.Change{After: opencdc.RawData(payload)},
+				},
+			})
+			if tt.wantErr != nil {
+				is.True(errors.Is(err, tt.wantErr))
+				is.Equal(count, 0)
+
+				return
+			}
+			is.NoErr(err)
+			is.Equal(count, 1)
+
+			var data []byte
+			for i := 0; i < tt.wantChunks; i++ {
+				msg, err := subscription.NextMsg(time.Second * 2)
+				is.NoErr(err)
+
+				if tt.cfg[ConfigOversizePolicy] == "split" {
+					is.Equal(msg.Header.Get(common.HeaderChunkIndex), strconv.Itoa(i))
+					is.Equal(msg.Header.Get(common.HeaderChunkCount), strconv.Itoa(tt.wantChunks))
+				}
+
+				data = append(data, msg.Data...)
+			}
+
+			_, err = subscription.NextMsg(time.Millisecond * 200)
+			is.True(errors.Is(err, nats.ErrTimeout))
+
+			switch {
+			case tt.wantData != nil:
+				is.Equal(data, tt.wantData)
+			case tt.wantChunks > 0:
+				is.Equal(data, payload)
+			}
+		})
+	}
+}
+
+func TestDestination_WriteCompressed(t *testing.T) {
+	is := is.New(t)
+
+	subject := "foo_destination_write_compressed"
+
+	testConn, err := nats.Connect(test.TestURL)
+	is.NoErr(err)
+
+	t.Cleanup(func() {
+		testConn.Flush()
+		testConn.Close()
+	})
+
+	subscription, err := testConn.SubscribeSync(subject)
+	is.NoErr(err)
+
+	destination := NewDestination()
+
+	err = destination.Configure(context.Background(), map[string]string{
+		ConfigUrls:               test.TestURL,
+		ConfigSubject:            subject,
+		ConfigCompressionCodec:   "gzip",
+		ConfigCompressionMinSize: "16",
+	})
+	is.NoErr(err)
+
+	err = destination.Open(context.Background())
+	is.NoErr(err)
+
+	t.Cleanup(func() {
+		is.NoErr(destination.Teardown(context.Background()))
+	})
+
+	large := bytes.Repeat([]byte("hello"), 100)
+
+	count, err := destination.Write(context.Background(), []opencdc.Record{
+		{
+			Operation: opencdc.OperationCreate,
+			Payload:   opencdc.Change{After: opencdc.RawData(large)},
+		},
+		{
+			Operation: opencdc.OperationCreate,
+			Payload:   opencdc.Change{After: opencdc.RawData("small")},
+		},
+	})
+	is.NoErr(err)
+	is.Equal(count, 2)
+
+	msg, err := subscription.NextMsg(time.Second * 2)
+	is.NoErr(err)
+	is.Equal(msg.Header.Get(common.HeaderContentEncoding), common.CompressionGzip)
+	is.True(len(msg.Data) < len(large))
+
+	data, err := common.Decompress(common.CompressionGzip, msg.Data, 0)
+	is.NoErr(err)
+	is.Equal(data, large)
+
+	// payloads below the minimum size are sent uncompressed
+	msg, err = subscription.NextMsg(time.Second * 2)
+	is.NoErr(err)
+	is.Equal(msg.Header.Get(common.HeaderContentEncoding), "")
+	is.Equal(msg.Data, []byte("small"))
+}
+
+func TestDestination_WriteEncrypted(t *testing.T) {
+	is := is.New(t)
+
+	subject := "foo_destination_write_encrypted"
+
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	t.Setenv("TEST_DESTINATION_KEY_k1", key)
+
+	testConn, err := nats.Connect(test.TestURL)
+	is.NoErr(err)
+
+	t.Cleanup(func() {
+		testConn.Flush()
+		testConn.Close()
+	})
+
+	subscription, err := testConn.SubscribeSync(subject)
+	is.NoErr(err)
+
+	destination := NewDestination()
+
+	err = destination.Configure(context.Background(), map[string]string{
+		ConfigUrls:                   test.TestURL,
+		ConfigSubject:                subject,
+		ConfigEncryptionKeyProvider:  common.KeyProviderEnv,
+		ConfigEncryptionKeyEnvPrefix: "TEST_DESTINATION_KEY_",
+		ConfigEncryptionKeyId:        "k1",
+	})
+	is.NoErr(err)
+
+	err = destination.Open(context.Background())
+	is.NoErr(err)
+
+	t.Cleanup(func() {
+		is.NoErr(destination.Teardown(context.Background()))
+	})
+
+	count, err := destination.Write(context.Background(), []opencdc.Record{
+		{
+			Operation: opencdc.OperationCreate,
+			Payload:   opencdc.Change{After: opencdc.RawData("secret")},
+		},
+	})
+	is.NoErr(err)
+	is.Equal(count, 1)
+
+	msg, err := subscription.NextMsg(time.Second * 2)
+	is.NoErr(err)
+	is.Equal(msg.Header.Get(common.HeaderEncryptionKeyID), "k1")
+	is.True(!bytes.Contains(msg.Data, []byte("secret")))
+
+	dataKey, err := base64.StdEncoding.DecodeString(msg.Header.Get(common.HeaderEncryptionDataKey))
+	is.NoErr(err)
+
+	data, err := common.Decrypt(&common.EnvKeyProvider{Prefix: "TEST_DESTINATION_KEY_"}, "k1", msg.Data, dataKey)
+	is.NoErr(err)
+	is.Equal(data, []byte("secret"))
+}
+
+func TestDestination_OpenMissingEncryptionKey(t *testing.T) {
+	is := is.New(t)
+
+	destination := NewDestination()
+
+	err := destination.Configure(context.Background(), map[string]string{
+		ConfigUrls:                   test.TestURL,
+		ConfigSubject:                "foo_destination_missing_key",
+		ConfigEncryptionKeyProvider:  common.KeyProviderEnv,
+		ConfigEncryptionKeyEnvPrefix: "TEST_DESTINATION_MISSING_KEY_",
+		ConfigEncryptionKeyId:        "k1",
+	})
+	is.NoErr(err)
+
+	err = destination.Open(context.Background())
+	is.True(errors.Is(err, common.ErrKeyNotFound))
+}
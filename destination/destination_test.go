@@ -40,6 +40,283 @@ func TestDestination_Configure(t *testing.T) {
 			cfg:     config.Config{},
 			wantErr: true,
 		},
+		{
+			name: "success, delete policy options",
+			cfg: config.Config{
+				ConfigUrls:                   "nats://127.0.0.1:4222",
+				ConfigSubject:                "foo",
+				ConfigDeletePolicy:           "skip",
+				ConfigTombstoneFormat:        "record",
+				ConfigOperationSubjectSuffix: "true",
+				ConfigOperationHeader:        "operation",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, invalid delete policy",
+			cfg: config.Config{
+				ConfigUrls:         "nats://127.0.0.1:4222",
+				ConfigSubject:      "foo",
+				ConfigDeletePolicy: "ignore",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid tombstone format",
+			cfg: config.Config{
+				ConfigUrls:            "nats://127.0.0.1:4222",
+				ConfigSubject:         "foo",
+				ConfigTombstoneFormat: "json",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, routes",
+			cfg: config.Config{
+				ConfigUrls:          "nats://127.0.0.1:4222",
+				ConfigSubject:       "foo",
+				ConfigRoutes:        `[{"operations":["create","update"],"collection":"users","subject":"users.changes"}]`,
+				ConfigDropUnmatched: "true",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, route without subject",
+			cfg: config.Config{
+				ConfigUrls:    "nats://127.0.0.1:4222",
+				ConfigSubject: "foo",
+				ConfigRoutes:  `[{"operations":["create"]}]`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, malformed routes",
+			cfg: config.Config{
+				ConfigUrls:    "nats://127.0.0.1:4222",
+				ConfigSubject: "foo",
+				ConfigRoutes:  `{"subject":"bar"}`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, drop unmatched without routes",
+			cfg: config.Config{
+				ConfigUrls:          "nats://127.0.0.1:4222",
+				ConfigSubject:       "foo",
+				ConfigDropUnmatched: "true",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, overflow policy",
+			cfg: config.Config{
+				ConfigUrls:            "nats://127.0.0.1:4222",
+				ConfigSubject:         "foo",
+				ConfigOversizePolicy:  "overflow",
+				ConfigOverflowSubject: "foo.overflow",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, overflow policy without overflow subject",
+			cfg: config.Config{
+				ConfigUrls:           "nats://127.0.0.1:4222",
+				ConfigSubject:        "foo",
+				ConfigOversizePolicy: "overflow",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid oversize policy",
+			cfg: config.Config{
+				ConfigUrls:           "nats://127.0.0.1:4222",
+				ConfigSubject:        "foo",
+				ConfigOversizePolicy: "truncate",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, disconnect policy fail",
+			cfg: config.Config{
+				ConfigUrls:             "nats://127.0.0.1:4222",
+				ConfigSubject:          "foo",
+				ConfigDisconnectPolicy: "fail",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, invalid disconnect policy",
+			cfg: config.Config{
+				ConfigUrls:             "nats://127.0.0.1:4222",
+				ConfigSubject:          "foo",
+				ConfigDisconnectPolicy: "drop",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, compression",
+			cfg: config.Config{
+				ConfigUrls:               "nats://127.0.0.1:4222",
+				ConfigSubject:            "foo",
+				ConfigCompressionCodec:   "zstd",
+				ConfigCompressionMinSize: "0",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, invalid compression codec",
+			cfg: config.Config{
+				ConfigUrls:             "nats://127.0.0.1:4222",
+				ConfigSubject:          "foo",
+				ConfigCompressionCodec: "lz4",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, encryption with env key provider",
+			cfg: config.Config{
+				ConfigUrls:                  "nats://127.0.0.1:4222",
+				ConfigSubject:               "foo",
+				ConfigEncryptionKeyProvider: "env",
+				ConfigEncryptionKeyId:       "k1",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, encryption without key id",
+			cfg: config.Config{
+				ConfigUrls:                  "nats://127.0.0.1:4222",
+				ConfigSubject:               "foo",
+				ConfigEncryptionKeyProvider: "env",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, file key provider without key dir",
+			cfg: config.Config{
+				ConfigUrls:                  "nats://127.0.0.1:4222",
+				ConfigSubject:               "foo",
+				ConfigEncryptionKeyProvider: "file",
+				ConfigEncryptionKeyId:       "k1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, inline nkey seed",
+			cfg: config.Config{
+				ConfigUrls:     "nats://127.0.0.1:4222",
+				ConfigSubject:  "foo",
+				ConfigNkeySeed: "env:NATS_NKEY_SEED",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, multiple auth methods",
+			cfg: config.Config{
+				ConfigUrls:     "nats://127.0.0.1:4222",
+				ConfigSubject:  "foo",
+				ConfigNkeyPath: "./seed.txt",
+				ConfigNkeySeed: "env:NATS_NKEY_SEED",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, jwt without seed",
+			cfg: config.Config{
+				ConfigUrls:    "nats://127.0.0.1:4222",
+				ConfigSubject: "foo",
+				ConfigJwt:     "env:NATS_USER_JWT",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, tls settings",
+			cfg: config.Config{
+				ConfigUrls:                  "tls://127.0.0.1:4222",
+				ConfigSubject:               "foo",
+				ConfigTlsServerName:         "nats.internal",
+				ConfigTlsMinVersion:         "1.3",
+				ConfigTlsCipherSuites:       "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+				ConfigTlsInsecureSkipVerify: "true",
+				ConfigTlsHandshakeFirst:     "true",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, unsupported tls min version",
+			cfg: config.Config{
+				ConfigUrls:          "tls://127.0.0.1:4222",
+				ConfigSubject:       "foo",
+				ConfigTlsMinVersion: "1.1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, unknown tls cipher suite",
+			cfg: config.Config{
+				ConfigUrls:            "tls://127.0.0.1:4222",
+				ConfigSubject:         "foo",
+				ConfigTlsCipherSuites: "TLS_RSA_WITH_RC4_128_SHA",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, metrics address",
+			cfg: config.Config{
+				ConfigUrls:           "nats://127.0.0.1:4222",
+				ConfigSubject:        "foo",
+				ConfigMetricsAddress: ":9464",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, metrics address without port",
+			cfg: config.Config{
+				ConfigUrls:           "nats://127.0.0.1:4222",
+				ConfigSubject:        "foo",
+				ConfigMetricsAddress: "localhost",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, CloudEvents structured mode",
+			cfg: config.Config{
+				ConfigUrls:                  "nats://127.0.0.1:4222",
+				ConfigSubject:               "foo",
+				ConfigCloudEventsMode:       "structured",
+				ConfigCloudEventsSource:     "/pipelines/orders",
+				ConfigCloudEventsTypePrefix: "com.example.orders",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, invalid CloudEvents mode",
+			cfg: config.Config{
+				ConfigUrls:            "nats://127.0.0.1:4222",
+				ConfigSubject:         "foo",
+				ConfigCloudEventsMode: "batched",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, Avro schema format",
+			cfg: config.Config{
+				ConfigUrls:          "nats://127.0.0.1:4222",
+				ConfigSubject:       "foo",
+				ConfigSchemaFormat:  "avro",
+				ConfigSchemaSubject: "orders.payload",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, unsupported schema format",
+			cfg: config.Config{
+				ConfigUrls:         "nats://127.0.0.1:4222",
+				ConfigSubject:      "foo",
+				ConfigSchemaFormat: "protobuf",
+			},
+			wantErr: true,
+		},
 		{
 			name: "fail, invalid config",
 			cfg: config.Config{
@@ -0,0 +1,172 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+)
+
+// ErrDisconnected occurs when a record is written while the connection is down and the
+// disconnect policy is "fail". The write can be retried once the connection is re-established.
+var ErrDisconnected = errors.New("not connected to NATS")
+
+// confirmTimeout is the maximum time to wait for the server to confirm the
+// messages flushed from the reconnect buffer.
+const confirmTimeout = 10 * time.Second
+
+// closeTimeout is the maximum time to wait for the tracker to count the messages
+// lost when the connection is closed.
+const closeTimeout = 5 * time.Second
+
+// DisconnectPolicy defines how records written while the connection is down are handled.
+type DisconnectPolicy string
+
+const (
+	// DisconnectPolicyBuffer publishes the messages to the reconnect buffer,
+	// they are sent once the connection is re-established.
+	DisconnectPolicyBuffer DisconnectPolicy = "buffer"
+	// DisconnectPolicyFail returns ErrDisconnected.
+	DisconnectPolicyFail DisconnectPolicy = "fail"
+)
+
+// BufferStats are the counts of messages published while the connection was down.
+type BufferStats struct {
+	// Buffered is the number of messages in the reconnect buffer waiting for the connection.
+	Buffered int64
+	// Confirmed is the number of buffered messages the server confirmed after reconnecting.
+	Confirmed int64
+	// Lost is the number of buffered messages dropped, because the connection was
+	// closed before it was re-established.
+	Lost int64
+}
+
+// BufferTracker tracks the messages the NATS client holds in its reconnect buffer
+// while the connection is down, and whether they reach the server after reconnecting.
+type BufferTracker struct {
+	logger *zerolog.Logger
+
+	mu    sync.Mutex
+	stats BufferStats
+
+	// done is closed once the lost messages of the closed connection are counted.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBufferTracker creates new instance of the BufferTracker.
+func NewBufferTracker(logger *zerolog.Logger) *BufferTracker {
+	if logger == nil {
+		nop := zerolog.Nop()
+		logger = &nop
+	}
+
+	return &BufferTracker{logger: logger, done: make(chan struct{})}
+}
+
+// Options returns the connection options reporting connection state changes to the tracker.
+func (t *BufferTracker) Options() []nats.Option {
+	return []nats.Option{
+		nats.DisconnectErrHandler(t.disconnected),
+		nats.ReconnectHandler(t.reconnected),
+		nats.ClosedHandler(t.closed),
+	}
+}
+
+// Stats returns the current counts of buffered messages.
+func (t *BufferTracker) Stats() BufferStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.stats
+}
+
+// buffered records a message published to the reconnect buffer.
+func (t *BufferTracker) buffered() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stats.Buffered++
+	common.MetricReconnectBufferedMessages.Inc()
+}
+
+func (t *BufferTracker) disconnected(_ *nats.Conn, err error) {
+	t.logger.Warn().Err(err).Msg("disconnected from NATS, buffering messages until reconnected")
+}
+
+// reconnected confirms the messages flushed from the reconnect buffer in the background,
+// so the other handlers of the connection aren't blocked meanwhile.
+func (t *BufferTracker) reconnected(conn *nats.Conn) {
+	go t.confirm(conn)
+}
+
+// confirm waits for the server to confirm the messages flushed from the reconnect buffer.
+func (t *BufferTracker) confirm(conn *nats.Conn) {
+	if err := conn.FlushTimeout(confirmTimeout); err != nil {
+		t.logger.Warn().Err(err).
+			Int64("buffered", t.Stats().Buffered).
+			Msg("reconnected to NATS, but the buffered messages weren't confirmed")
+
+		return
+	}
+
+	t.mu.Lock()
+	confirmed := t.stats.Buffered
+	t.stats.Confirmed += confirmed
+	t.stats.Buffered = 0
+	t.mu.Unlock()
+
+	common.MetricReconnectBufferedMessages.Sub(float64(confirmed))
+
+	t.logger.Info().
+		Int64("confirmed", confirmed).
+		Msg("reconnected to NATS, the buffered messages were confirmed")
+}
+
+// closed counts the messages still in the reconnect buffer as lost.
+func (t *BufferTracker) closed(*nats.Conn) {
+	t.mu.Lock()
+	lost := t.stats.Buffered
+	t.stats.Lost += lost
+	t.stats.Buffered = 0
+	t.mu.Unlock()
+
+	common.MetricReconnectBufferedMessages.Sub(float64(lost))
+
+	if lost > 0 {
+		t.logger.Error().
+			Int64("lost", lost).
+			Msg("connection to NATS closed before the buffered messages were sent")
+	}
+
+	t.closeOnce.Do(func() { close(t.done) })
+}
+
+// waitClosed waits until the messages lost by closing the connection are counted,
+// since the client reports the closed connection asynchronously. It returns false
+// if that doesn't happen within the timeout.
+func (t *BufferTracker) waitClosed(timeout time.Duration) bool {
+	select {
+	case <-t.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// startServer starts a NATS server listening on the port.
+func startServer(t *testing.T, port int) *server.Server {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("create server: %v", err)
+	}
+
+	go srv.Start()
+
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("server isn't ready for connections")
+	}
+
+	t.Cleanup(srv.Shutdown)
+
+	return srv
+}
+
+// newTrackedWriter connects to the server and returns a writer tracking the reconnect buffer.
+func newTrackedWriter(t *testing.T, srv *server.Server, policy DisconnectPolicy) (*Writer, *nats.Conn) {
+	t.Helper()
+
+	tracker := NewBufferTracker(nil)
+
+	opts := append(tracker.Options(), nats.MaxReconnects(-1), nats.ReconnectWait(50*time.Millisecond))
+
+	conn, err := nats.Connect(srv.ClientURL(), opts...)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	t.Cleanup(conn.Close)
+
+	w, err := NewWriter(WriterParams{
+		Conn:             &common.Conn{Conn: conn},
+		Subject:          "buffer",
+		DisconnectPolicy: policy,
+		BufferTracker:    tracker,
+	})
+	if err != nil {
+		t.Fatalf("create writer: %v", err)
+	}
+
+	return w, conn
+}
+
+// waitFor polls the condition until it's true or the timeout expires.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func testRecord() opencdc.Record {
+	return opencdc.Record{
+		Operation: opencdc.OperationCreate,
+		Payload:   opencdc.Change{After: opencdc.RawData("data")},
+	}
+}
+
+func TestWriter_BufferWhileDisconnected(t *testing.T) {
+	is := is.New(t)
+
+	srv := startServer(t, server.RANDOM_PORT)
+	port := srv.Addr().(*net.TCPAddr).Port
+
+	w, conn := newTrackedWriter(t, srv, DisconnectPolicyBuffer)
+
+	is.NoErr(w.Write(context.Background(), testRecord()))
+	is.Equal(w.BufferStats(), BufferStats{})
+
+	srv.Shutdown()
+	waitFor(t, func() bool { return !conn.IsConnected() })
+
+	for range 3 {
+		is.NoErr(w.Write(context.Background(), testRecord()))
+	}
+
+	is.Equal(w.BufferStats(), BufferStats{Buffered: 3})
+
+	startServer(t, port)
+	waitFor(t, func() bool { return w.BufferStats().Confirmed == 3 })
+
+	is.Equal(w.BufferStats(), BufferStats{Confirmed: 3})
+}
+
+func TestWriter_BufferLostOnClose(t *testing.T) {
+	is := is.New(t)
+
+	srv := startServer(t, server.RANDOM_PORT)
+
+	w, conn := newTrackedWriter(t, srv, DisconnectPolicyBuffer)
+
+	srv.Shutdown()
+	waitFor(t, func() bool { return !conn.IsConnected() })
+
+	is.NoErr(w.Write(context.Background(), testRecord()))
+	is.NoErr(w.Write(context.Background(), testRecord()))
+
+	// the lost messages are counted by the time Close returns
+	is.NoErr(w.Close())
+	is.Equal(w.BufferStats(), BufferStats{Lost: 2})
+}
+
+func TestWriter_FailWhileDisconnected(t *testing.T) {
+	is := is.New(t)
+
+	srv := startServer(t, server.RANDOM_PORT)
+	port := srv.Addr().(*net.TCPAddr).Port
+
+	w, conn := newTrackedWriter(t, srv, DisconnectPolicyFail)
+
+	is.NoErr(w.Write(context.Background(), testRecord()))
+
+	srv.Shutdown()
+	waitFor(t, func() bool { return !conn.IsConnected() })
+
+	err := w.Write(context.Background(), testRecord())
+	is.True(errors.Is(err, ErrDisconnected))
+	is.Equal(w.BufferStats(), BufferStats{})
+
+	// the write succeeds again once reconnected
+	startServer(t, port)
+	waitFor(t, conn.IsConnected)
+
+	is.NoErr(w.Write(context.Background(), testRecord()))
+}
@@ -0,0 +1,166 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// CloudEventsMode defines whether and how records are published as CloudEvents.
+type CloudEventsMode string
+
+const (
+	// CloudEventsModeNone publishes records as plain messages.
+	CloudEventsModeNone CloudEventsMode = "none"
+	// CloudEventsModeBinary publishes the event attributes in ce- headers and the event data as the payload.
+	CloudEventsModeBinary CloudEventsMode = "binary"
+	// CloudEventsModeStructured publishes the event attributes and data in a JSON envelope.
+	CloudEventsModeStructured CloudEventsMode = "structured"
+)
+
+// cloudEventsAttrRegex matches valid CloudEvents attribute names.
+var cloudEventsAttrRegex = regexp.MustCompile(`^[a-z0-9]{1,20}$`)
+
+// cloudEvent turns the message into a CloudEvent according to the Writer's CloudEvents mode.
+func (w *Writer) cloudEvent(msg *nats.Msg, record opencdc.Record) error {
+	switch w.cloudEventsMode {
+	case CloudEventsModeBinary:
+		for attr, value := range w.cloudEventAttributes(record, msg.Header.Get(common.HeaderContentType)) {
+			// header values can't span multiple lines
+			if strings.ContainsAny(value, "\r\n") {
+				continue
+			}
+
+			if attr == common.CloudEventsAttrDataContentType {
+				msg.Header.Set(common.HeaderContentType, value)
+
+				continue
+			}
+
+			msg.Header.Set(common.CloudEventsHeaderPrefix+attr, value)
+		}
+
+		return nil
+
+	case CloudEventsModeStructured:
+		data, err := structuredCloudEvent(w.cloudEventAttributes(record, msg.Header.Get(common.HeaderContentType)), msg.Data)
+		if err != nil {
+			return err
+		}
+
+		msg.Data = data
+		msg.Header.Set(common.HeaderContentType, common.CloudEventsContentType)
+
+		return nil
+
+	case CloudEventsModeNone:
+		fallthrough
+	default:
+		return nil
+	}
+}
+
+// cloudEventAttributes returns the event attributes of the record. The attributes in the
+// record metadata are kept, missing required attributes are derived from the record, as
+// well as the subject from the record key, the time from the record creation time, and
+// the data content type from the content type of the payload, or of structured data.
+func (w *Writer) cloudEventAttributes(record opencdc.Record, contentType string) map[string]string {
+	attrs := make(map[string]string)
+	for key, value := range record.Metadata {
+		if attr, ok := strings.CutPrefix(key, common.CloudEventsMetadataPrefix); ok && cloudEventsAttrRegex.MatchString(attr) {
+			attrs[attr] = value
+		}
+	}
+
+	attrs[common.CloudEventsAttrSpecVersion] = common.CloudEventsSpecVersion
+
+	setDefault(attrs, common.CloudEventsAttrID, cloudEventID(record))
+	setDefault(attrs, common.CloudEventsAttrSource, w.cloudEventsSource)
+
+	eventType := record.Operation.String()
+	if w.cloudEventsTypePrefix != "" {
+		eventType = w.cloudEventsTypePrefix + "." + eventType
+	}
+
+	setDefault(attrs, common.CloudEventsAttrType, eventType)
+
+	if key := string(dataBytes(record.Key)); key != "" && utf8.ValidString(key) {
+		setDefault(attrs, common.CloudEventsAttrSubject, key)
+	}
+
+	if createdAt, err := record.Metadata.GetCreatedAt(); err == nil {
+		setDefault(attrs, common.CloudEventsAttrTime, createdAt.UTC().Format(time.RFC3339Nano))
+	}
+
+	if contentType != "" {
+		setDefault(attrs, common.CloudEventsAttrDataContentType, contentType)
+	} else if _, ok := record.Payload.After.(opencdc.StructuredData); ok && record.Operation != opencdc.OperationDelete {
+		setDefault(attrs, common.CloudEventsAttrDataContentType, "application/json")
+	}
+
+	return attrs
+}
+
+// cloudEventID returns an event id derived from the record position, so a record
+// written again gets the same id, or a random id if the record has no position.
+func cloudEventID(record opencdc.Record) string {
+	if len(record.Position) == 0 {
+		return uuid.NewString()
+	}
+
+	return base64.RawURLEncoding.EncodeToString(record.Position)
+}
+
+// structuredCloudEvent returns the JSON envelope of an event with the attributes and data.
+// JSON data is embedded as it is, other data is base64-encoded.
+func structuredCloudEvent(attrs map[string]string, data []byte) ([]byte, error) {
+	event := make(map[string]any, len(attrs)+1)
+	for attr, value := range attrs {
+		event[attr] = value
+	}
+
+	switch {
+	case data == nil:
+	case common.IsJSONContentType(attrs[common.CloudEventsAttrDataContentType]) && json.Valid(data):
+		event["data"] = json.RawMessage(data)
+	default:
+		event["data_base64"] = base64.StdEncoding.EncodeToString(data)
+	}
+
+	envelope, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("marshal CloudEvent: %w", err)
+	}
+
+	return envelope, nil
+}
+
+// setDefault sets the attribute, unless it's already set.
+func setDefault(attrs map[string]string, attr, value string) {
+	if _, ok := attrs[attr]; !ok {
+		attrs[attr] = value
+	}
+}
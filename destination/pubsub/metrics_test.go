@@ -0,0 +1,72 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestWriter_Metrics(t *testing.T) {
+	is := is.New(t)
+
+	srv := startServer(t, server.RANDOM_PORT)
+
+	conn, err := nats.Connect(srv.ClientURL())
+	is.NoErr(err)
+
+	t.Cleanup(conn.Close)
+
+	w, err := NewWriter(WriterParams{
+		Conn:        &common.Conn{Conn: conn},
+		Subject:     "metrics.writer",
+		SkipDeletes: true,
+		Routes:      []Route{{Collection: "users", Subject: "metrics.writer.users"}},
+	})
+	is.NoErr(err)
+
+	published := common.MetricMessagesPublished.WithLabelValues("metrics.writer")
+	publishedBytes := common.MetricBytesPublished.WithLabelValues("metrics.writer")
+	routed := common.MetricMessagesPublished.WithLabelValues("metrics.writer.users")
+	droppedDeletes := common.MetricMessagesDropped.WithLabelValues(common.DropReasonDelete)
+
+	before := []float64{
+		testutil.ToFloat64(published),
+		testutil.ToFloat64(publishedBytes),
+		testutil.ToFloat64(routed),
+		testutil.ToFloat64(droppedDeletes),
+	}
+
+	is.NoErr(w.Write(context.Background(), testRecord()))
+	is.NoErr(w.Write(context.Background(), testRecord()))
+
+	users := testRecord()
+	users.Metadata = opencdc.Metadata{opencdc.MetadataCollection: "users"}
+	is.NoErr(w.Write(context.Background(), users))
+
+	is.NoErr(w.Write(context.Background(), opencdc.Record{Operation: opencdc.OperationDelete}))
+
+	is.Equal(testutil.ToFloat64(published)-before[0], 2.0)
+	is.Equal(testutil.ToFloat64(publishedBytes)-before[1], float64(2*len("data")))
+	is.Equal(testutil.ToFloat64(routed)-before[2], 1.0)
+	is.Equal(testutil.ToFloat64(droppedDeletes)-before[3], 1.0)
+}
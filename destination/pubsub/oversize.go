@@ -0,0 +1,151 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// ErrMessageTooLarge occurs when a message exceeds the server's max payload.
+var ErrMessageTooLarge = errors.New("message exceeds the server's max payload")
+
+// OversizePolicy defines how messages exceeding the server's max payload are handled.
+type OversizePolicy string
+
+const (
+	// OversizePolicyFail returns an error.
+	OversizePolicyFail OversizePolicy = "fail"
+	// OversizePolicySkip drops the message and logs a warning.
+	OversizePolicySkip OversizePolicy = "skip"
+	// OversizePolicySplit publishes the payload in chunks carrying reassembly headers.
+	OversizePolicySplit OversizePolicy = "split"
+	// OversizePolicyOverflow publishes the record key to the overflow subject instead.
+	OversizePolicyOverflow OversizePolicy = "overflow"
+)
+
+// writeOversized handles a message exceeding the server's max payload according to the Writer's oversize policy.
+func (w *Writer) writeOversized(ctx context.Context, msg *nats.Msg, record opencdc.Record, size int) error {
+	switch w.oversizePolicy {
+	case OversizePolicySkip:
+		w.logger.Warn().
+			Str("subject", msg.Subject).
+			Int("size", size).
+			Int("maxPayload", w.maxPayload).
+			Msg("skipping a record that exceeds the server's max payload")
+		common.MetricMessagesDropped.WithLabelValues(common.DropReasonOversize).Inc()
+
+		return nil
+
+	case OversizePolicySplit:
+		return w.publishChunks(ctx, msg)
+
+	case OversizePolicyOverflow:
+		return w.publishOverflow(msg, record, size)
+
+	case OversizePolicyFail:
+		fallthrough
+	default:
+		return fmt.Errorf("%d bytes, max payload is %d bytes: %w", size, w.maxPayload, ErrMessageTooLarge)
+	}
+}
+
+// publishChunks splits the message payload into chunks that fit into the server's max payload
+// and publishes them in order. Every chunk carries the headers of the original message
+// and the headers needed to reassemble it. It stops between chunks if the context is done.
+func (w *Writer) publishChunks(ctx context.Context, msg *nats.Msg) error {
+	id := uuid.NewString()
+
+	// reserve room for the chunk headers, assuming their largest possible values
+	reserved := maps.Clone(msg.Header)
+	reserved.Set(common.HeaderChunkID, id)
+	reserved.Set(common.HeaderChunkIndex, strconv.Itoa(len(msg.Data)))
+	reserved.Set(common.HeaderChunkCount, strconv.Itoa(len(msg.Data)))
+
+	chunkSize := w.maxPayload - headerSize(reserved)
+	if chunkSize <= 0 {
+		return fmt.Errorf("message headers leave no room for chunks: %w", ErrMessageTooLarge)
+	}
+
+	count := (len(msg.Data) + chunkSize - 1) / chunkSize
+	for i := 0; i < count; i++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("write canceled after %d of %d chunks: %w", i, count, err)
+		}
+
+		chunk := nats.NewMsg(msg.Subject)
+		chunk.Header = maps.Clone(msg.Header)
+		chunk.Header.Set(common.HeaderChunkID, id)
+		chunk.Header.Set(common.HeaderChunkIndex, strconv.Itoa(i))
+		chunk.Header.Set(common.HeaderChunkCount, strconv.Itoa(count))
+		chunk.Data = msg.Data[i*chunkSize : min((i+1)*chunkSize, len(msg.Data))]
+
+		if err := w.publish(chunk); err != nil {
+			return fmt.Errorf("failed to publish chunk %d of %d: %w", i+1, count, err)
+		}
+	}
+
+	return nil
+}
+
+// publishOverflow publishes the record key to the overflow subject, along with
+// the headers of the original message and headers describing it.
+func (w *Writer) publishOverflow(msg *nats.Msg, record opencdc.Record, size int) error {
+	overflow := nats.NewMsg(w.overflowSubject)
+	overflow.Header = maps.Clone(msg.Header)
+	overflow.Header.Set(common.HeaderOversizeSubject, msg.Subject)
+	overflow.Header.Set(common.HeaderOversizeBytes, strconv.Itoa(size))
+	overflow.Data = dataBytes(record.Key)
+
+	if overflowSize := msgSize(overflow); overflowSize > w.maxPayload {
+		return fmt.Errorf("overflow message of %d bytes, max payload is %d bytes: %w",
+			overflowSize, w.maxPayload, ErrMessageTooLarge)
+	}
+
+	if err := w.publish(overflow); err != nil {
+		return fmt.Errorf("failed to publish overflow message: %w", err)
+	}
+
+	return nil
+}
+
+// msgSize returns the number of bytes the message occupies of the server's max payload.
+func msgSize(msg *nats.Msg) int {
+	return headerSize(msg.Header) + len(msg.Data)
+}
+
+// headerSize returns the size of the headers encoded the way the NATS client sends them.
+func headerSize(header nats.Header) int {
+	if len(header) == 0 {
+		return 0
+	}
+
+	var b bytes.Buffer
+	b.WriteString("NATS/1.0\r\n")
+	_ = http.Header(header).Write(&b)
+	b.WriteString("\r\n")
+
+	return b.Len()
+}
@@ -0,0 +1,57 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"slices"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// Route defines a subject which records matching all the route conditions are published to.
+type Route struct {
+	// Operations contains operations the record operation must be one of.
+	// The route matches any operation if empty.
+	Operations []opencdc.Operation
+	// Collection is a collection the record's collection metadata must be equal to.
+	// The route matches any collection if empty.
+	Collection string
+	// Metadata contains entries the record metadata must contain.
+	Metadata map[string]string
+	// Subject is a subject the matching records are published to.
+	Subject string
+}
+
+// Matches checks if the record satisfies all the route conditions.
+func (r Route) Matches(record opencdc.Record) bool {
+	if len(r.Operations) > 0 && !slices.Contains(r.Operations, record.Operation) {
+		return false
+	}
+
+	if r.Collection != "" {
+		collection, err := record.Metadata.GetCollection()
+		if err != nil || collection != r.Collection {
+			return false
+		}
+	}
+
+	for key, value := range r.Metadata {
+		if v, ok := record.Metadata[key]; !ok || v != value {
+			return false
+		}
+	}
+
+	return true
+}
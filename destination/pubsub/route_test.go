@@ -0,0 +1,85 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+func TestRoute_Matches(t *testing.T) {
+	record := opencdc.Record{
+		Operation: opencdc.OperationUpdate,
+		Metadata: opencdc.Metadata{
+			opencdc.MetadataCollection: "users",
+			"source":                   "pg",
+		},
+	}
+
+	tests := []struct {
+		name  string
+		route Route
+		want  bool
+	}{
+		{
+			name:  "true, no conditions",
+			route: Route{Subject: "foo"},
+			want:  true,
+		},
+		{
+			name: "true, all conditions match",
+			route: Route{
+				Operations: []opencdc.Operation{opencdc.OperationCreate, opencdc.OperationUpdate},
+				Collection: "users",
+				Metadata:   map[string]string{"source": "pg"},
+				Subject:    "foo",
+			},
+			want: true,
+		},
+		{
+			name: "false, operation doesn't match",
+			route: Route{
+				Operations: []opencdc.Operation{opencdc.OperationDelete},
+				Subject:    "foo",
+			},
+			want: false,
+		},
+		{
+			name: "false, collection doesn't match",
+			route: Route{
+				Collection: "orders",
+				Subject:    "foo",
+			},
+			want: false,
+		},
+		{
+			name: "false, metadata key is missing",
+			route: Route{
+				Metadata: map[string]string{"region": "eu"},
+				Subject:  "foo",
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.route.Matches(record); got != tt.want {
+				t.Errorf("Route.Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/nats-io/nats.go"
+)
+
+// SchemaFormat defines how structured payloads are encoded.
+type SchemaFormat string
+
+const (
+	// SchemaFormatNone publishes structured payloads as JSON.
+	SchemaFormatNone SchemaFormat = "none"
+	// SchemaFormatAvro publishes structured payloads encoded with an Avro schema
+	// registered in Conduit's schema service.
+	SchemaFormatAvro SchemaFormat = "avro"
+)
+
+// encodePayload encodes the structured payload of the record according to the Writer's
+// schema format, and sets the headers identifying the schema. Raw payloads and
+// tombstones are published as they are.
+func (w *Writer) encodePayload(ctx context.Context, msg *nats.Msg, record opencdc.Record) error {
+	data, ok := record.Payload.After.(opencdc.StructuredData)
+	if w.schemaFormat != SchemaFormatAvro || !ok || record.Operation == opencdc.OperationDelete {
+		return nil
+	}
+
+	sch, err := w.payloadSchema(ctx, msg.Subject, record.Metadata, data)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := sch.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode payload: %w", err)
+	}
+
+	msg.Data = encoded
+	msg.Header.Set(common.HeaderContentType, common.AvroContentType)
+	msg.Header.Set(common.HeaderSchemaSubject, sch.Subject)
+	msg.Header.Set(common.HeaderSchemaVersion, strconv.Itoa(sch.Version))
+	msg.Header.Set(common.HeaderSchemaID, strconv.Itoa(sch.ID))
+
+	return nil
+}
+
+// payloadSchema returns the schema of the payload. It's the schema the record metadata
+// refers to, if any, otherwise a schema extracted from the payload is registered under
+// the Writer's schema subject, or the message subject followed by ".payload".
+func (w *Writer) payloadSchema(
+	ctx context.Context,
+	subject string,
+	metadata opencdc.Metadata,
+	data opencdc.StructuredData,
+) (schema.Schema, error) {
+	schemaSubject, err := metadata.GetPayloadSchemaSubject()
+	if err != nil && !errors.Is(err, opencdc.ErrMetadataFieldNotFound) {
+		return schema.Schema{}, fmt.Errorf("get payload schema subject: %w", err)
+	}
+
+	version, err := metadata.GetPayloadSchemaVersion()
+	if err != nil && !errors.Is(err, opencdc.ErrMetadataFieldNotFound) {
+		return schema.Schema{}, fmt.Errorf("get payload schema version: %w", err)
+	}
+
+	if schemaSubject != "" && version > 0 {
+		sch, err := schema.Get(ctx, schemaSubject, version)
+		if err != nil {
+			return schema.Schema{}, fmt.Errorf("get payload schema %s:%d: %w", schemaSubject, version, err)
+		}
+
+		return sch, nil
+	}
+
+	srd, err := schema.KnownSerdeFactories[schema.TypeAvro].SerdeForType(data)
+	if err != nil {
+		return schema.Schema{}, fmt.Errorf("extract payload schema: %w", err)
+	}
+
+	schemaSubject = w.schemaSubject
+	if schemaSubject == "" {
+		schemaSubject = subject + ".payload"
+	}
+
+	sch, err := schema.Create(ctx, schema.TypeAvro, schemaSubject, []byte(srd.String()))
+	if err != nil {
+		return schema.Schema{}, fmt.Errorf("create payload schema %s: %w", schemaSubject, err)
+	}
+
+	return sch, nil
+}
@@ -0,0 +1,185 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// newSchemaWriter returns a Writer encoding payloads with Avro schemas and a subscription to its subject.
+func newSchemaWriter(t *testing.T, subject string, cloudEventsMode CloudEventsMode) (*Writer, *nats.Subscription) {
+	t.Helper()
+
+	srv := startServer(t, server.RANDOM_PORT)
+
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	t.Cleanup(conn.Close)
+
+	sub, err := conn.SubscribeSync(subject)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	w, err := NewWriter(WriterParams{
+		Conn:            &common.Conn{Conn: conn},
+		Subject:         subject,
+		SchemaFormat:    SchemaFormatAvro,
+		CloudEventsMode: cloudEventsMode,
+	})
+	if err != nil {
+		t.Fatalf("create writer: %v", err)
+	}
+
+	return w, sub
+}
+
+// decodeMessage decodes the payload of the message with the schema its headers refer to.
+func decodeMessage(t *testing.T, msg *nats.Msg) (opencdc.StructuredData, int) {
+	t.Helper()
+
+	version, err := strconv.Atoi(msg.Header.Get(common.HeaderSchemaVersion))
+	if err != nil {
+		t.Fatalf("parse schema version: %v", err)
+	}
+
+	sch, err := schema.Get(context.Background(), msg.Header.Get(common.HeaderSchemaSubject), version)
+	if err != nil {
+		t.Fatalf("get schema: %v", err)
+	}
+
+	if id := msg.Header.Get(common.HeaderSchemaID); id != strconv.Itoa(sch.ID) {
+		t.Fatalf("schema id header = %q, want %d", id, sch.ID)
+	}
+
+	var data opencdc.StructuredData
+	if err := sch.Unmarshal(msg.Data, &data); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	return data, version
+}
+
+func TestWriter_EncodePayload_SchemaEvolution(t *testing.T) {
+	is := is.New(t)
+
+	w, sub := newSchemaWriter(t, "evolution.orders", CloudEventsModeNone)
+
+	payloads := []opencdc.StructuredData{
+		{"id": int64(1), "status": "new"},
+		// added field
+		{"id": int64(2), "status": "paid", "total": 9.5},
+		// removed field
+		{"id": int64(3), "total": 12.0},
+		// same fields as before
+		{"id": int64(4), "total": 3.0},
+	}
+
+	for _, payload := range payloads {
+		record := testRecord()
+		record.Payload.After = payload
+		is.NoErr(w.Write(context.Background(), record))
+	}
+
+	versions := make([]int, 0, len(payloads))
+	for _, want := range payloads {
+		msg, err := sub.NextMsg(2 * time.Second)
+		is.NoErr(err)
+		is.Equal(msg.Header.Get(common.HeaderContentType), common.AvroContentType)
+		is.Equal(msg.Header.Get(common.HeaderSchemaSubject), "evolution.orders.payload")
+
+		got, version := decodeMessage(t, msg)
+		is.Equal(got, want)
+
+		versions = append(versions, version)
+	}
+
+	// a new schema version is registered whenever fields are added or removed
+	is.True(versions[0] < versions[1])
+	is.True(versions[1] < versions[2])
+	is.Equal(versions[2], versions[3])
+}
+
+func TestWriter_EncodePayload(t *testing.T) {
+	t.Run("schema from the record metadata", func(t *testing.T) {
+		is := is.New(t)
+
+		sch, err := schema.Create(context.Background(), schema.TypeAvro, "metadata.orders",
+			[]byte(`{"type":"record","name":"order","fields":[{"name":"id","type":"long"},`+
+				`{"name":"note","type":["null","string"],"default":null}]}`))
+		is.NoErr(err)
+
+		w, sub := newSchemaWriter(t, "metadata", CloudEventsModeNone)
+
+		record := testRecord()
+		record.Metadata = opencdc.Metadata{}
+		record.Metadata.SetPayloadSchemaSubject(sch.Subject)
+		record.Metadata.SetPayloadSchemaVersion(sch.Version)
+		record.Payload.After = opencdc.StructuredData{"id": int64(7), "note": nil}
+
+		is.NoErr(w.Write(context.Background(), record))
+
+		msg, err := sub.NextMsg(2 * time.Second)
+		is.NoErr(err)
+		is.Equal(msg.Header.Get(common.HeaderSchemaSubject), sch.Subject)
+
+		got, version := decodeMessage(t, msg)
+		is.Equal(version, sch.Version)
+		is.Equal(got, opencdc.StructuredData{"id": int64(7), "note": nil})
+	})
+
+	t.Run("raw data", func(t *testing.T) {
+		is := is.New(t)
+
+		w, sub := newSchemaWriter(t, "raw", CloudEventsModeNone)
+
+		is.NoErr(w.Write(context.Background(), testRecord()))
+
+		msg, err := sub.NextMsg(2 * time.Second)
+		is.NoErr(err)
+		is.Equal(string(msg.Data), "data")
+		is.Equal(msg.Header.Get(common.HeaderSchemaSubject), "")
+	})
+
+	t.Run("CloudEvents data content type", func(t *testing.T) {
+		is := is.New(t)
+
+		w, sub := newSchemaWriter(t, "events", CloudEventsModeBinary)
+
+		record := testRecord()
+		record.Payload.After = opencdc.StructuredData{"id": int64(1)}
+		is.NoErr(w.Write(context.Background(), record))
+
+		msg, err := sub.NextMsg(2 * time.Second)
+		is.NoErr(err)
+		is.Equal(msg.Header.Get(common.HeaderContentType), common.AvroContentType)
+
+		got, _ := decodeMessage(t, msg)
+		is.Equal(got, opencdc.StructuredData{"id": int64(1)})
+	})
+}
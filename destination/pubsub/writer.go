@@ -15,47 +15,347 @@
 package pubsub
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
 	"github.com/conduitio/conduit-commons/opencdc"
 	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+)
+
+// TombstoneFormat defines the payload of a message published for a deleted record.
+type TombstoneFormat string
+
+const (
+	// TombstoneFormatEmpty publishes an empty payload.
+	TombstoneFormatEmpty TombstoneFormat = "empty"
+	// TombstoneFormatKey publishes the record key.
+	TombstoneFormatKey TombstoneFormat = "key"
+	// TombstoneFormatRecord publishes the whole serialized record.
+	TombstoneFormatRecord TombstoneFormat = "record"
 )
 
 // Writer implements a PubSub writer.
 // It writes messages synchronously. It doesn't support batching/async writing.
 type Writer struct {
-	conn    *nats.Conn
-	subject string
+	conn                   *common.Conn
+	subject                string
+	skipDeletes            bool
+	tombstoneFormat        TombstoneFormat
+	operationSubjectSuffix bool
+	operationHeader        string
+	routes                 []Route
+	dropUnmatched          bool
+	maxPayload             int
+	oversizePolicy         OversizePolicy
+	overflowSubject        string
+	compression            string
+	compressionMinSize     int
+	keyProvider            common.KeyProvider
+	keyID                  string
+	disconnectPolicy       DisconnectPolicy
+	bufferTracker          *BufferTracker
+	cloudEventsMode        CloudEventsMode
+	cloudEventsSource      string
+	cloudEventsTypePrefix  string
+	schemaFormat           SchemaFormat
+	schemaSubject          string
+	logger                 *zerolog.Logger
 }
 
 // WriterParams is an incoming params for the NewWriter function.
 type WriterParams struct {
-	Conn    *nats.Conn
+	Conn    *common.Conn
 	Subject string
+	// SkipDeletes drops records with the delete operation instead of publishing them.
+	SkipDeletes bool
+	// TombstoneFormat defines the payload of messages published for deleted records.
+	TombstoneFormat TombstoneFormat
+	// OperationSubjectSuffix appends the record operation to the subject as an additional token.
+	OperationSubjectSuffix bool
+	// OperationHeader is the name of a header that carries the record operation, ignored if empty.
+	OperationHeader string
+	// Routes select the subject a record is published to, the first matching route wins.
+	// Records that match no route are published to the Subject.
+	Routes []Route
+	// DropUnmatched drops records that match no route instead of publishing them to the Subject.
+	DropUnmatched bool
+	// OversizePolicy defines how messages exceeding the server's max payload are handled.
+	OversizePolicy OversizePolicy
+	// OverflowSubject is the subject oversized records are diverted to by the OversizePolicyOverflow.
+	OverflowSubject string
+	// Compression is the codec used to compress message payloads, see common.Compress.
+	Compression string
+	// CompressionMinSize is the size in bytes below which payloads are sent uncompressed.
+	CompressionMinSize int
+	// KeyProvider provides the key used to encrypt message payloads, encryption is disabled if nil.
+	KeyProvider common.KeyProvider
+	// KeyID is the id of the key used to encrypt message payloads.
+	KeyID string
+	// DisconnectPolicy defines how records written while the connection is down are handled.
+	DisconnectPolicy DisconnectPolicy
+	// BufferTracker tracks the messages published while the connection is down, if not nil.
+	// Its options must be passed when connecting.
+	BufferTracker *BufferTracker
+	// CloudEventsMode defines whether and how records are published as CloudEvents.
+	CloudEventsMode CloudEventsMode
+	// CloudEventsSource is the source attribute of events whose record metadata has none.
+	CloudEventsSource string
+	// CloudEventsTypePrefix prefixes the record operation in the type attribute of events
+	// whose record metadata has none.
+	CloudEventsTypePrefix string
+	// SchemaFormat defines how structured payloads are encoded.
+	SchemaFormat SchemaFormat
+	// SchemaSubject is the subject schemas extracted from payloads are registered under.
+	// If empty, it's the subject the message is published to followed by ".payload".
+	SchemaSubject string
+	// Logger is used to report skipped records.
+	Logger *zerolog.Logger
 }
 
 // NewWriter creates new instance of the Writer.
 func NewWriter(params WriterParams) (*Writer, error) {
+	logger := params.Logger
+	if logger == nil {
+		nop := zerolog.Nop()
+		logger = &nop
+	}
+
 	return &Writer{
-		conn:    params.Conn,
-		subject: params.Subject,
+		conn:                   params.Conn,
+		subject:                params.Subject,
+		skipDeletes:            params.SkipDeletes,
+		tombstoneFormat:        params.TombstoneFormat,
+		operationSubjectSuffix: params.OperationSubjectSuffix,
+		operationHeader:        params.OperationHeader,
+		routes:                 params.Routes,
+		dropUnmatched:          params.DropUnmatched,
+		maxPayload:             int(params.Conn.MaxPayload()),
+		oversizePolicy:         params.OversizePolicy,
+		overflowSubject:        params.OverflowSubject,
+		compression:            params.Compression,
+		compressionMinSize:     params.CompressionMinSize,
+		keyProvider:            params.KeyProvider,
+		keyID:                  params.KeyID,
+		disconnectPolicy:       params.DisconnectPolicy,
+		bufferTracker:          params.BufferTracker,
+		cloudEventsMode:        params.CloudEventsMode,
+		cloudEventsSource:      params.CloudEventsSource,
+		cloudEventsTypePrefix:  params.CloudEventsTypePrefix,
+		schemaFormat:           params.SchemaFormat,
+		schemaSubject:          params.SchemaSubject,
+		logger:                 logger,
 	}, nil
 }
 
 // Write writes directly and synchronously a record to a subject.
-func (w *Writer) Write(record opencdc.Record) error {
-	err := w.conn.Publish(w.subject, record.Payload.After.Bytes())
+// It stops before publishing if the context is done.
+func (w *Writer) Write(ctx context.Context, record opencdc.Record) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("write canceled: %w", err)
+	}
+
+	if record.Operation == opencdc.OperationDelete && w.skipDeletes {
+		common.MetricMessagesDropped.WithLabelValues(common.DropReasonDelete).Inc()
+
+		return nil
+	}
+
+	subject, ok := w.subjectFor(record)
+	if !ok {
+		common.MetricMessagesDropped.WithLabelValues(common.DropReasonUnmatched).Inc()
+
+		return nil
+	}
+
+	if w.disconnectPolicy == DisconnectPolicyFail && !w.conn.IsConnected() {
+		return fmt.Errorf("%w: connection is %s", ErrDisconnected, w.conn.Status())
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = w.payloadFor(record)
+
+	if err := w.encodePayload(ctx, msg, record); err != nil {
+		return err
+	}
+
+	if w.operationHeader != "" {
+		msg.Header.Set(w.operationHeader, record.Operation.String())
+	}
+
+	common.InjectTraceContext(ctx, record.Metadata, msg.Header)
+
+	if err := w.cloudEvent(msg, record); err != nil {
+		return err
+	}
+
+	if err := w.compress(msg); err != nil {
+		return err
+	}
+
+	if err := w.encrypt(msg); err != nil {
+		return err
+	}
+
+	if w.maxPayload == 0 {
+		// the max payload is unknown until the connection is established
+		w.maxPayload = int(w.conn.MaxPayload())
+	}
+
+	if size := msgSize(msg); w.maxPayload > 0 && size > w.maxPayload {
+		return w.writeOversized(ctx, msg, record, size)
+	}
+
+	err := w.publish(msg)
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
 	return nil
 }
 
-// Close closes the underlying NATS connection.
+// Flush waits until the server processed the messages published so far, or the
+// context is done. The context must have a deadline. Messages in the reconnect
+// buffer are confirmed by the BufferTracker once reconnected, so with the buffer
+// policy Flush returns right away while the connection is down.
+func (w *Writer) Flush(ctx context.Context) error {
+	if w.disconnectPolicy != DisconnectPolicyFail && !w.conn.IsConnected() {
+		return nil
+	}
+
+	if err := w.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+
+	return nil
+}
+
+// BufferStats returns the counts of messages published while the connection was down.
+func (w *Writer) BufferStats() BufferStats {
+	if w.bufferTracker == nil {
+		return BufferStats{}
+	}
+
+	return w.bufferTracker.Stats()
+}
+
+// publish publishes the message, and tracks it if it went to the reconnect buffer.
+func (w *Writer) publish(msg *nats.Msg) error {
+	if err := w.conn.PublishMsg(msg); err != nil {
+		return err //nolint:wrapcheck // the callers wrap the error
+	}
+
+	common.MetricMessagesPublished.WithLabelValues(msg.Subject).Inc()
+	common.MetricBytesPublished.WithLabelValues(msg.Subject).Add(float64(len(msg.Data)))
+
+	if w.bufferTracker != nil && !w.conn.IsConnected() {
+		w.bufferTracker.buffered()
+	}
+
+	return nil
+}
+
+// Close closes the underlying NATS connection, and waits until the messages lost
+// with it are counted, so BufferStats reports them.
 func (w *Writer) Close() error {
-	if w.conn != nil {
-		w.conn.Close()
+	if w.conn == nil {
+		return nil
+	}
+
+	w.conn.Close()
+
+	// a shared connection stays open for the other connectors, its buffered messages aren't lost
+	if w.bufferTracker != nil && w.conn.IsClosed() && !w.bufferTracker.waitClosed(closeTimeout) {
+		w.logger.Warn().Msg("the messages lost by closing the connection to NATS weren't counted in time")
+	}
+
+	return nil
+}
+
+// compress compresses the message payload with the Writer's codec, unless
+// compression is disabled or the payload is smaller than the minimum size.
+func (w *Writer) compress(msg *nats.Msg) error {
+	if w.compression == "" || w.compression == common.CompressionNone || len(msg.Data) < w.compressionMinSize {
+		return nil
+	}
+
+	data, err := common.Compress(w.compression, msg.Data)
+	if err != nil {
+		return fmt.Errorf("compress payload: %w", err)
+	}
+
+	msg.Data = data
+	msg.Header.Set(common.HeaderContentEncoding, w.compression)
+
+	return nil
+}
+
+// encrypt envelope-encrypts the message payload with the Writer's key, unless encryption is disabled.
+func (w *Writer) encrypt(msg *nats.Msg) error {
+	if w.keyProvider == nil {
+		return nil
 	}
 
+	data, dataKey, err := common.Encrypt(w.keyProvider, w.keyID, msg.Data)
+	if err != nil {
+		return fmt.Errorf("encrypt payload: %w", err)
+	}
+
+	msg.Data = data
+	msg.Header.Set(common.HeaderEncryptionKeyID, w.keyID)
+	msg.Header.Set(common.HeaderEncryptionDataKey, base64.StdEncoding.EncodeToString(dataKey))
+
 	return nil
 }
+
+// subjectFor returns the subject the record should be published to.
+// It returns false if the record matches no route and should be dropped.
+func (w *Writer) subjectFor(record opencdc.Record) (string, bool) {
+	subject, matched := w.subject, false
+	for _, route := range w.routes {
+		if route.Matches(record) {
+			subject, matched = route.Subject, true
+
+			break
+		}
+	}
+
+	if !matched && w.dropUnmatched {
+		return "", false
+	}
+
+	if w.operationSubjectSuffix {
+		subject += "." + record.Operation.String()
+	}
+
+	return subject, true
+}
+
+// payloadFor returns the message payload for the record.
+// Deleted records are published as tombstones formatted according to the Writer's tombstone format.
+func (w *Writer) payloadFor(record opencdc.Record) []byte {
+	if record.Operation != opencdc.OperationDelete {
+		return dataBytes(record.Payload.After)
+	}
+
+	switch w.tombstoneFormat {
+	case TombstoneFormatKey:
+		return dataBytes(record.Key)
+	case TombstoneFormatRecord:
+		return record.Bytes()
+	case TombstoneFormatEmpty:
+		return nil
+	default:
+		return nil
+	}
+}
+
+// dataBytes returns the bytes of the data, or nil if there is no data.
+func dataBytes(data opencdc.Data) []byte {
+	if data == nil {
+		return nil
+	}
+
+	return data.Bytes()
+}
@@ -0,0 +1,198 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+func TestWriter_TraceContext(t *testing.T) {
+	is := is.New(t)
+
+	srv := startServer(t, server.RANDOM_PORT)
+
+	conn, err := nats.Connect(srv.ClientURL())
+	is.NoErr(err)
+
+	t.Cleanup(conn.Close)
+
+	sub, err := conn.SubscribeSync("trace")
+	is.NoErr(err)
+
+	w, err := NewWriter(WriterParams{Conn: &common.Conn{Conn: conn}, Subject: "trace"})
+	is.NoErr(err)
+
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	record := testRecord()
+	record.Metadata = opencdc.Metadata{
+		common.MetadataTraceParent: traceParent,
+		common.MetadataTraceState:  "vendor=value",
+	}
+
+	is.NoErr(w.Write(context.Background(), record))
+	is.NoErr(w.Write(context.Background(), testRecord()))
+
+	msg, err := sub.NextMsg(2 * time.Second)
+	is.NoErr(err)
+	is.Equal(msg.Header.Get("traceparent"), traceParent)
+	is.Equal(msg.Header.Get("tracestate"), "vendor=value")
+
+	// records without a trace context are published without trace headers
+	msg, err = sub.NextMsg(2 * time.Second)
+	is.NoErr(err)
+	is.Equal(msg.Header.Get("traceparent"), "")
+}
+
+func TestWriter_CloudEvents(t *testing.T) {
+	srv := startServer(t, server.RANDOM_PORT)
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	record := opencdc.Record{
+		Position:  opencdc.Position("pos-1"),
+		Operation: opencdc.OperationUpdate,
+		Key:       opencdc.RawData("order-1"),
+		Metadata: opencdc.Metadata{
+			opencdc.MetadataCreatedAt: strconv.FormatInt(createdAt.UnixNano(), 10),
+			"cloudevents.tenant":      "acme",
+			"cloudevents.Invalid":     "skipped",
+		},
+		Payload: opencdc.Change{After: opencdc.StructuredData{"id": 1}},
+	}
+
+	wantAttrs := map[string]string{
+		"specversion":     "1.0",
+		"id":              "cG9zLTE",
+		"source":          "/conduit",
+		"type":            "io.conduit.record.update",
+		"subject":         "order-1",
+		"time":            "2026-01-02T03:04:05Z",
+		"datacontenttype": "application/json",
+		"tenant":          "acme",
+	}
+
+	publish := func(t *testing.T, mode CloudEventsMode, record opencdc.Record) *nats.Msg {
+		t.Helper()
+
+		conn, err := nats.Connect(srv.ClientURL())
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+
+		t.Cleanup(conn.Close)
+
+		sub, err := conn.SubscribeSync("events")
+		if err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+
+		w, err := NewWriter(WriterParams{
+			Conn:                  &common.Conn{Conn: conn},
+			Subject:               "events",
+			CloudEventsMode:       mode,
+			CloudEventsSource:     "/conduit",
+			CloudEventsTypePrefix: "io.conduit.record",
+		})
+		if err != nil {
+			t.Fatalf("create writer: %v", err)
+		}
+
+		if err := w.Write(context.Background(), record); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+
+		msg, err := sub.NextMsg(2 * time.Second)
+		if err != nil {
+			t.Fatalf("next message: %v", err)
+		}
+
+		return msg
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		is := is.New(t)
+
+		msg := publish(t, CloudEventsModeBinary, record)
+		is.Equal(string(msg.Data), `{"id":1}`)
+
+		attrs := make(map[string]string)
+		for name, values := range msg.Header {
+			if attr, ok := strings.CutPrefix(name, "ce-"); ok {
+				attrs[attr] = values[0]
+			}
+		}
+
+		attrs["datacontenttype"] = msg.Header.Get("Content-Type")
+		is.Equal(attrs, wantAttrs)
+	})
+
+	t.Run("structured", func(t *testing.T) {
+		is := is.New(t)
+
+		msg := publish(t, CloudEventsModeStructured, record)
+		is.Equal(msg.Header.Get("Content-Type"), "application/cloudevents+json")
+
+		var event map[string]any
+		is.NoErr(json.Unmarshal(msg.Data, &event))
+		is.Equal(event["data"], map[string]any{"id": 1.0})
+
+		delete(event, "data")
+
+		attrs := make(map[string]string)
+		for attr, value := range event {
+			attrs[attr], _ = value.(string)
+		}
+
+		is.Equal(attrs, wantAttrs)
+	})
+
+	t.Run("structured, raw data and attributes from metadata", func(t *testing.T) {
+		is := is.New(t)
+
+		raw := opencdc.Record{
+			Operation: opencdc.OperationCreate,
+			Metadata: opencdc.Metadata{
+				"cloudevents.id":     "evt-1",
+				"cloudevents.source": "/orders",
+				"cloudevents.type":   "order.created",
+			},
+			Payload: opencdc.Change{After: opencdc.RawData{0, 1}},
+		}
+
+		msg := publish(t, CloudEventsModeStructured, raw)
+
+		var event map[string]any
+		is.NoErr(json.Unmarshal(msg.Data, &event))
+		is.Equal(event, map[string]any{
+			"specversion": "1.0",
+			"id":          "evt-1",
+			"source":      "/orders",
+			"type":        "order.created",
+			"data_base64": "AAE=",
+		})
+	})
+}
@@ -0,0 +1,260 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// startBlackhole starts a server that accepts NATS connections, answers the
+// initial ping, and then silently discards everything it receives.
+func startBlackhole(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go blackhole(conn)
+		}
+	}()
+
+	return "nats://" + ln.Addr().String()
+}
+
+// blackhole completes the NATS handshake on the connection and discards everything after it.
+func blackhole(conn net.Conn) {
+	defer conn.Close()
+
+	_, err := io.WriteString(conn, `INFO {"server_id":"blackhole","version":"2.10.0","proto":1,"headers":true,"max_payload":1048576}`+"\r\n")
+	if err != nil {
+		return
+	}
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		if strings.HasPrefix(line, "PING") {
+			if _, err := io.WriteString(conn, "PONG\r\n"); err != nil {
+				return
+			}
+
+			break
+		}
+	}
+
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// openDestination configures and opens a destination publishing to the URL.
+func openDestination(t *testing.T, url, writeTimeout string) *Destination {
+	t.Helper()
+
+	d := &Destination{}
+
+	err := d.Configure(context.Background(), map[string]string{
+		ConfigUrls:         url,
+		ConfigSubject:      "foo",
+		ConfigWriteTimeout: writeTimeout,
+	})
+	if err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+
+	if err := d.Open(context.Background()); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	t.Cleanup(func() { _ = d.Teardown(context.Background()) })
+
+	return d
+}
+
+func testRecords(n int) []opencdc.Record {
+	records := make([]opencdc.Record, n)
+	for i := range records {
+		records[i] = opencdc.Record{
+			Operation: opencdc.OperationCreate,
+			Payload:   opencdc.Change{After: opencdc.RawData("data")},
+		}
+	}
+
+	return records
+}
+
+func TestDestination_WriteTimeout(t *testing.T) {
+	is := is.New(t)
+
+	d := openDestination(t, startBlackhole(t), "200ms")
+
+	start := time.Now()
+
+	// the server never confirms the batch
+	n, err := d.Write(context.Background(), testRecords(3))
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	is.Equal(n, 0)
+	is.True(time.Since(start) < 5*time.Second)
+}
+
+// cancelingWriter cancels the context after a number of records were written.
+type cancelingWriter struct {
+	Writer
+
+	cancel func()
+	after  int
+}
+
+func (w *cancelingWriter) Write(ctx context.Context, record opencdc.Record) error {
+	if err := w.Writer.Write(ctx, record); err != nil {
+		return err //nolint:wrapcheck // the test checks the error of the wrapped writer
+	}
+
+	w.after--
+	if w.after == 0 {
+		w.cancel()
+	}
+
+	return nil
+}
+
+func TestDestination_WriteCanceled(t *testing.T) {
+	is := is.New(t)
+
+	d := openDestination(t, startBlackhole(t), "10s")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d.writer = &cancelingWriter{Writer: d.writer, cancel: cancel, after: 2}
+
+	// the batch stops at the first record written after the cancellation,
+	// and the records before it can't be confirmed anymore
+	n, err := d.Write(ctx, testRecords(5))
+	is.True(errors.Is(err, context.Canceled))
+	is.Equal(n, 0)
+}
+
+// failingWriter fails to write the records after a number of records were written.
+// It fails with err, or blocks until the context is done if err is nil.
+type failingWriter struct {
+	Writer
+
+	after int
+	err   error
+}
+
+func (w *failingWriter) Write(ctx context.Context, record opencdc.Record) error {
+	if w.after > 0 {
+		w.after--
+
+		return w.Writer.Write(ctx, record) //nolint:wrapcheck // the test checks the error of the wrapped writer
+	}
+
+	if w.err != nil {
+		return w.err
+	}
+
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+func TestDestination_WriteTimeoutMidBatch(t *testing.T) {
+	is := is.New(t)
+
+	d := openDestination(t, startBlackhole(t), "200ms")
+	d.writer = &failingWriter{Writer: d.writer, after: 2}
+
+	// the third record times out, the records before it were published, but never confirmed
+	n, err := d.Write(context.Background(), testRecords(5))
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	is.Equal(n, 0)
+}
+
+func TestDestination_WriteFailsMidBatch(t *testing.T) {
+	is := is.New(t)
+
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	is.NoErr(err)
+
+	go srv.Start()
+
+	is.True(srv.ReadyForConnections(5 * time.Second))
+	t.Cleanup(srv.Shutdown)
+
+	errWrite := errors.New("write failed")
+
+	d := openDestination(t, srv.ClientURL(), "10s")
+	d.writer = &failingWriter{Writer: d.writer, after: 2, err: errWrite}
+
+	// the records before the failing one are confirmed
+	n, err := d.Write(context.Background(), testRecords(5))
+	is.True(errors.Is(err, errWrite))
+	is.Equal(n, 2)
+}
+
+func TestDestination_OpenSharedConnection(t *testing.T) {
+	is := is.New(t)
+
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	is.NoErr(err)
+
+	go srv.Start()
+
+	is.True(srv.ReadyForConnections(5 * time.Second))
+	t.Cleanup(srv.Shutdown)
+
+	// destinations without a connection name get the same generated name, so they share the connection
+	for _, subject := range []string{"foo", "bar"} {
+		d := &Destination{}
+
+		err := d.Configure(context.Background(), map[string]string{
+			ConfigUrls:             srv.ClientURL(),
+			ConfigSubject:          subject,
+			ConfigSharedConnection: "true",
+		})
+		is.NoErr(err)
+		is.NoErr(d.Open(context.Background()))
+
+		t.Cleanup(func() { _ = d.Teardown(context.Background()) })
+	}
+
+	is.Equal(srv.NumClients(), 1)
+}
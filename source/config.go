@@ -17,6 +17,9 @@
 package source
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
 )
 
@@ -26,4 +29,63 @@ type Config struct {
 
 	// A buffer size for consumed messages.
 	BufferSize int `json:"bufferSize" default:"1024" validate:"gt=63"`
+	// If true, CloudEvents in binary mode (ce- headers) and in structured mode
+	// (application/cloudevents+json content type) are parsed. Their attributes are
+	// added to the record metadata prefixed with "cloudevents.", and their data
+	// becomes the record payload.
+	CloudEvents bool `json:"cloudEvents" default:"false"`
+
+	Chunks        ChunksConfig            `json:"chunks"`
+	Decompression DecompressionConfig     `json:"decompression"`
+	Encryption    common.EncryptionConfig `json:"encryption"`
+	Metrics       common.MetricsConfig    `json:"metrics"`
+	Schema        SchemaConfig            `json:"schema"`
+}
+
+// ChunksConfig holds the configuration of reassembling messages
+// that were split into chunks by the destination.
+type ChunksConfig struct {
+	// The maximum time to wait for all the chunks of a split message,
+	// incomplete messages are dropped afterwards.
+	Timeout time.Duration `json:"timeout" default:"1m"`
+	// The maximum number of bytes held by incomplete split messages. When it's
+	// exceeded, the oldest incomplete messages are dropped. Zero means no limit.
+	MaxBytes int `json:"maxBytes" default:"67108864" validate:"gt=-1"`
+}
+
+// DecompressionConfig holds the configuration of decompressing payloads
+// compressed by the destination.
+type DecompressionConfig struct {
+	// The maximum number of bytes of a decompressed payload. Messages that exceed it
+	// fail to be read. Zero means no limit.
+	MaxBytes int `json:"maxBytes" default:"67108864" validate:"gt=-1"`
+}
+
+// SchemaConfig holds the configuration of inferring payload schemas.
+type SchemaConfig struct {
+	// If true, payloads that are JSON objects are decoded into structured data, and
+	// an Avro schema inferred from them is registered in Conduit's schema service and
+	// attached to the record metadata. A new schema version is registered whenever
+	// the inferred shape of the payloads changes.
+	Infer bool `json:"infer" default:"false"`
+	// The subject inferred schemas are registered under. If empty, it's the subject
+	// the message was published to followed by ".payload".
+	Subject string `json:"subject"`
+}
+
+// Validate checks the config values that can't be expressed as parameter validations.
+func (c Config) Validate() error {
+	if err := c.Config.Validate(); err != nil {
+		return fmt.Errorf("validate connection config: %w", err)
+	}
+
+	if err := c.Encryption.Validate(); err != nil {
+		return fmt.Errorf("validate encryption: %w", err)
+	}
+
+	if err := c.Metrics.Validate(); err != nil {
+		return fmt.Errorf("validate metrics: %w", err)
+	}
+
+	return nil
 }
@@ -9,16 +9,58 @@ import (
 
 const (
 	ConfigBufferSize              = "bufferSize"
+	ConfigChunksMaxBytes          = "chunks.maxBytes"
+	ConfigChunksTimeout           = "chunks.timeout"
+	ConfigCloudEvents             = "cloudEvents"
+	ConfigConnectTimeout          = "connectTimeout"
 	ConfigConnectionName          = "connectionName"
 	ConfigCredentialsFilePath     = "credentialsFilePath"
+	ConfigDecompressionMaxBytes   = "decompression.maxBytes"
+	ConfigDontRandomize           = "dontRandomize"
+	ConfigEncryptionKeyDir        = "encryption.keyDir"
+	ConfigEncryptionKeyEnvPrefix  = "encryption.keyEnvPrefix"
+	ConfigEncryptionKeyProvider   = "encryption.keyProvider"
+	ConfigFlusherTimeout          = "flusherTimeout"
+	ConfigInboxPrefix             = "inboxPrefix"
+	ConfigJwt                     = "jwt"
+	ConfigMaxPingsOutstanding     = "maxPingsOutstanding"
 	ConfigMaxReconnects           = "maxReconnects"
+	ConfigMetricsAddress          = "metrics.address"
 	ConfigNkeyPath                = "nkeyPath"
+	ConfigNkeySeed                = "nkeySeed"
+	ConfigNoEcho                  = "noEcho"
+	ConfigPassword                = "password"
+	ConfigPingInterval            = "pingInterval"
+	ConfigProxyPassword           = "proxy.password"
+	ConfigProxyTimeout            = "proxy.timeout"
+	ConfigProxyUrl                = "proxy.url"
+	ConfigProxyUsername           = "proxy.username"
+	ConfigReconnectBufferSize     = "reconnectBufferSize"
+	ConfigReconnectJitter         = "reconnectJitter"
+	ConfigReconnectJitterTLS      = "reconnectJitterTLS"
 	ConfigReconnectWait           = "reconnectWait"
+	ConfigRetryOnFailedConnect    = "retryOnFailedConnect"
+	ConfigSchemaInfer             = "schema.infer"
+	ConfigSchemaSubject           = "schema.subject"
+	ConfigSharedConnection        = "sharedConnection"
+	ConfigStartupWait             = "startupWait"
 	ConfigSubject                 = "subject"
+	ConfigTlsCipherSuites         = "tls.cipherSuites"
+	ConfigTlsClientCert           = "tls.clientCert"
 	ConfigTlsClientCertPath       = "tls.clientCertPath"
+	ConfigTlsClientPrivateKey     = "tls.clientPrivateKey"
 	ConfigTlsClientPrivateKeyPath = "tls.clientPrivateKeyPath"
+	ConfigTlsHandshakeFirst       = "tls.handshakeFirst"
+	ConfigTlsInsecureSkipVerify   = "tls.insecureSkipVerify"
+	ConfigTlsMinVersion           = "tls.minVersion"
+	ConfigTlsRootCACert           = "tls.rootCACert"
 	ConfigTlsRootCACertPath       = "tls.rootCACertPath"
+	ConfigTlsServerName           = "tls.serverName"
+	ConfigToken                   = "token"
 	ConfigUrls                    = "urls"
+	ConfigUsername                = "username"
+	ConfigWebsocketCompression    = "websocket.compression"
+	ConfigWebsocketProxyPath      = "websocket.proxyPath"
 )
 
 func (Config) Parameters() map[string]config.Parameter {
@@ -31,6 +73,32 @@ func (Config) Parameters() map[string]config.Parameter {
 				config.ValidationGreaterThan{V: 63},
 			},
 		},
+		ConfigChunksMaxBytes: {
+			Default:     "67108864",
+			Description: "The maximum number of bytes held by incomplete split messages. When it's\nexceeded, the oldest incomplete messages are dropped. Zero means no limit.",
+			Type:        config.ParameterTypeInt,
+			Validations: []config.Validation{
+				config.ValidationGreaterThan{V: -1},
+			},
+		},
+		ConfigChunksTimeout: {
+			Default:     "1m",
+			Description: "The maximum time to wait for all the chunks of a split message,\nincomplete messages are dropped afterwards.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
+		ConfigCloudEvents: {
+			Default:     "false",
+			Description: "If true, CloudEvents in binary mode (ce- headers) and in structured mode\n(application/cloudevents+json content type) are parsed. Their attributes are\nadded to the record metadata prefixed with \"cloudevents.\", and their data\nbecomes the record payload.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		ConfigConnectTimeout: {
+			Default:     "2s",
+			Description: "The maximum time to establish a connection to a server.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
 		ConfigConnectionName: {
 			Default:     "",
 			Description: "Optional connection name (can come in handy when it comes to monitoring).",
@@ -43,24 +111,188 @@ func (Config) Parameters() map[string]config.Parameter {
 			Type:        config.ParameterTypeString,
 			Validations: []config.Validation{},
 		},
+		ConfigDecompressionMaxBytes: {
+			Default:     "67108864",
+			Description: "The maximum number of bytes of a decompressed payload. Messages that exceed it\nfail to be read. Zero means no limit.",
+			Type:        config.ParameterTypeInt,
+			Validations: []config.Validation{
+				config.ValidationGreaterThan{V: -1},
+			},
+		},
+		ConfigDontRandomize: {
+			Default:     "",
+			Description: "Connect to the servers in the order of the URLs, instead of a random order.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		ConfigEncryptionKeyDir: {
+			Default:     "",
+			Description: "A path to a directory containing the key files.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigEncryptionKeyEnvPrefix: {
+			Default:     "NATS_PUBSUB_KEY_",
+			Description: "A prefix of the environment variables containing the keys.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigEncryptionKeyProvider: {
+			Default:     "none",
+			Description: "Where the encryption keys are loaded from. \"none\" disables encryption,\n\"file\" loads keys from files in encryption.keyDir named after the key ids,\n\"env\" loads keys from environment variables named encryption.keyEnvPrefix\nfollowed by the key id. Keys must be base64-encoded 16, 24 or 32 byte AES keys.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{
+				config.ValidationInclusion{List: []string{"none", "file", "env"}},
+			},
+		},
+		ConfigFlusherTimeout: {
+			Default:     "1m",
+			Description: "The maximum time to write buffered messages to the server before the\nconnection is considered stale.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
+		ConfigInboxPrefix: {
+			Default:     "",
+			Description: "The prefix of the subjects used for replies, instead of \"_INBOX\".",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigJwt: {
+			Default:     "",
+			Description: "A user JWT, used together with nkeySeed instead of credentialsFilePath.\nUse \"env:NAME\" to read it from the environment variable NAME.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigMaxPingsOutstanding: {
+			Default:     "2",
+			Description: "The number of pings without a reply before the connection is considered stale.",
+			Type:        config.ParameterTypeInt,
+			Validations: []config.Validation{
+				config.ValidationGreaterThan{V: 0},
+			},
+		},
 		ConfigMaxReconnects: {
 			Default:     "5",
 			Description: "Sets the number of reconnect attempts that will be tried before giving up.\nIf negative, it will never give up trying to reconnect.",
 			Type:        config.ParameterTypeInt,
 			Validations: []config.Validation{},
 		},
+		ConfigMetricsAddress: {
+			Default:     "",
+			Description: "The address the connector's metrics are served at in the Prometheus text\nformat under /metrics, e.g. \":9464\". If empty, the metrics aren't served.\nConnectors running in the same process with the same address share the endpoint.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
 		ConfigNkeyPath: {
 			Default:     "",
 			Description: "A path pointed to a NKey pair.",
 			Type:        config.ParameterTypeString,
 			Validations: []config.Validation{},
 		},
+		ConfigNkeySeed: {
+			Default:     "",
+			Description: "An NKey seed, used instead of nkeyPath, or together with jwt instead of\ncredentialsFilePath. Use \"env:NAME\" to read it from the environment variable NAME.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigNoEcho: {
+			Default:     "",
+			Description: "Don't deliver messages published by this connection to its own subscriptions.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		ConfigPassword: {
+			Default:     "",
+			Description: "A password for username/password authentication.\nUse \"env:NAME\" to read it from the environment variable NAME.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigPingInterval: {
+			Default:     "2m",
+			Description: "The interval of pings sent to the server to check the connection.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
+		ConfigProxyPassword: {
+			Default:     "",
+			Description: "A password to authenticate to the proxy with.\nUse \"env:NAME\" to read it from the environment variable NAME.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigProxyTimeout: {
+			Default:     "10s",
+			Description: "The maximum time to establish a connection through the proxy.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
+		ConfigProxyUrl: {
+			Default:     "",
+			Description: "The URL of a proxy to connect through, \"socks5://host:port\" for a SOCKS5\nproxy or \"http://host:port\" for an HTTP CONNECT proxy.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigProxyUsername: {
+			Default:     "",
+			Description: "A username to authenticate to the proxy with.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigReconnectBufferSize: {
+			Default:     "8388608",
+			Description: "The size in bytes of the buffer holding outgoing messages while reconnecting.\nSet it to -1 to disable buffering, publishing then fails while disconnected.",
+			Type:        config.ParameterTypeInt,
+			Validations: []config.Validation{
+				config.ValidationGreaterThan{V: -2},
+			},
+		},
+		ConfigReconnectJitter: {
+			Default:     "100ms",
+			Description: "The maximum random time added to reconnectWait for plain connections,\nso clients don't reconnect all at once.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
+		ConfigReconnectJitterTLS: {
+			Default:     "1s",
+			Description: "The maximum random time added to reconnectWait for TLS connections.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
 		ConfigReconnectWait: {
 			Default:     "5s",
 			Description: "Sets the time to backoff after attempting a reconnect to a server that we\nwere already connected to previously, formatted as a time.Duration string.",
 			Type:        config.ParameterTypeDuration,
 			Validations: []config.Validation{},
 		},
+		ConfigRetryOnFailedConnect: {
+			Default:     "",
+			Description: "Keep trying to connect in the background if no server can be reached initially,\ninstead of failing right away.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		ConfigSchemaInfer: {
+			Default:     "false",
+			Description: "If true, payloads that are JSON objects are decoded into structured data, and\nan Avro schema inferred from them is registered in Conduit's schema service and\nattached to the record metadata. A new schema version is registered whenever\nthe inferred shape of the payloads changes.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		ConfigSchemaSubject: {
+			Default:     "",
+			Description: "The subject inferred schemas are registered under. If empty, it's the subject\nthe message was published to followed by \".payload\".",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigSharedConnection: {
+			Default:     "",
+			Description: "Share one connection with the other connectors in this process that have\nidentical connection settings, instead of opening a connection per connector.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		ConfigStartupWait: {
+			Default:     "30s",
+			Description: "The maximum time to wait for the initial connection when retryOnFailedConnect\nis set. If 0, the connector starts right away and connects in the background.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
 		ConfigSubject: {
 			Default:     "",
 			Description: "The name of a subject which the connector should use to read/write records.",
@@ -69,24 +301,80 @@ func (Config) Parameters() map[string]config.Parameter {
 				config.ValidationRequired{},
 			},
 		},
+		ConfigTlsCipherSuites: {
+			Default:     "",
+			Description: "A comma-separated list of allowed cipher suites, e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256.\nApplies to TLS 1.2 only, TLS 1.3 cipher suites aren't configurable. Defaults to Go's secure defaults.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigTlsClientCert: {
+			Default:     "",
+			Description: "A PEM-encoded TLS client certificate, used instead of tls.clientCertPath.\nUse \"env:NAME\" to read it from the environment variable NAME.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
 		ConfigTlsClientCertPath: {
 			Default:     "",
 			Description: "A path pointed to a TLS client certificate, must be present if\ntls.clientPrivateKeyPath field is also present.",
 			Type:        config.ParameterTypeString,
 			Validations: []config.Validation{},
 		},
+		ConfigTlsClientPrivateKey: {
+			Default:     "",
+			Description: "A PEM-encoded TLS client private key, used instead of tls.clientPrivateKeyPath.\nUse \"env:NAME\" to read it from the environment variable NAME.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
 		ConfigTlsClientPrivateKeyPath: {
 			Default:     "",
 			Description: "A path pointed to a TLS client private key, must be present if\ntls.clientCertPath field is also present.",
 			Type:        config.ParameterTypeString,
 			Validations: []config.Validation{},
 		},
+		ConfigTlsHandshakeFirst: {
+			Default:     "",
+			Description: "Perform the TLS handshake before the server sends its INFO message, required\nfor servers behind TLS-terminating proxies.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		ConfigTlsInsecureSkipVerify: {
+			Default:     "",
+			Description: "Skip verifying the server's certificate. Only use it with development clusters.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		ConfigTlsMinVersion: {
+			Default:     "1.2",
+			Description: "The minimum TLS version to accept.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{
+				config.ValidationInclusion{List: []string{"1.2", "1.3"}},
+			},
+		},
+		ConfigTlsRootCACert: {
+			Default:     "",
+			Description: "A PEM-encoded TLS root certificate, used instead of tls.rootCACertPath.\nUse \"env:NAME\" to read it from the environment variable NAME.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
 		ConfigTlsRootCACertPath: {
 			Default:     "",
 			Description: "A path pointed to a TLS root certificate, provide it if you want to verify\nthe server's identity.",
 			Type:        config.ParameterTypeString,
 			Validations: []config.Validation{},
 		},
+		ConfigTlsServerName: {
+			Default:     "",
+			Description: "The server name used to verify the server's certificate, overrides the host of the URLs.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigToken: {
+			Default:     "",
+			Description: "A token for token authentication, used instead of embedding the token in the URLs.\nUse \"env:NAME\" to read it from the environment variable NAME.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
 		ConfigUrls: {
 			Default:     "",
 			Description: "A comma-separated list of connection URLs pointing to NATS instances.",
@@ -95,5 +383,23 @@ func (Config) Parameters() map[string]config.Parameter {
 				config.ValidationRequired{},
 			},
 		},
+		ConfigUsername: {
+			Default:     "",
+			Description: "A username for username/password authentication, used instead of embedding\nthe credentials in the URLs.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		ConfigWebsocketCompression: {
+			Default:     "",
+			Description: "Enables per-message compression of WebSocket frames, if the server supports it.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		ConfigWebsocketProxyPath: {
+			Default:     "",
+			Description: "A path added to the URLs, for servers behind a proxy that routes\nWebSocket connections by path, e.g. \"/nats\".",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
 	}
 }
@@ -0,0 +1,231 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+)
+
+// ErrInvalidChunk occurs when a message carries malformed chunk headers.
+var ErrInvalidChunk = errors.New("invalid chunk headers")
+
+// assembler reassembles messages that were split into chunks by the destination.
+// It isn't safe for concurrent use.
+type assembler struct {
+	timeout  time.Duration
+	maxBytes int
+	logger   *zerolog.Logger
+
+	pending map[string]*chunkSet
+	bytes   int
+	now     func() time.Time
+}
+
+// chunkSet holds the received chunks of a single message.
+type chunkSet struct {
+	header   nats.Header
+	count    int
+	chunks   map[int][]byte
+	received int
+	bytes    int
+	started  time.Time
+}
+
+// newAssembler creates new instance of the assembler.
+func newAssembler(timeout time.Duration, maxBytes int, logger *zerolog.Logger) *assembler {
+	return &assembler{
+		timeout:  timeout,
+		maxBytes: maxBytes,
+		logger:   logger,
+		pending:  make(map[string]*chunkSet),
+		now:      time.Now,
+	}
+}
+
+// add adds the message to the assembler.
+// It returns messages that aren't chunks as they are, a reassembled message once its
+// last chunk arrives, and nil if the message is a chunk of an incomplete message.
+// Malformed chunks are dropped.
+func (a *assembler) add(msg *nats.Msg) *nats.Msg {
+	id := msg.Header.Get(common.HeaderChunkID)
+	if id == "" {
+		return msg
+	}
+
+	a.expire()
+
+	index, count, err := parseChunkHeaders(msg.Header, a.maxBytes)
+	if err != nil {
+		a.logger.Warn().Err(err).Str("chunkId", id).Msg("dropping a malformed chunk")
+		common.MetricMessagesDropped.WithLabelValues(common.DropReasonInvalidChunk).Inc()
+
+		return nil
+	}
+
+	set, ok := a.pending[id]
+	if !ok {
+		set = &chunkSet{
+			header:  msg.Header,
+			count:   count,
+			chunks:  make(map[int][]byte),
+			started: a.now(),
+		}
+		a.pending[id] = set
+	}
+
+	if _, duplicate := set.chunks[index]; set.count != count || duplicate {
+		a.logger.Warn().Str("chunkId", id).Int("index", index).Msg("dropping a duplicate or inconsistent chunk")
+		common.MetricMessagesDropped.WithLabelValues(common.DropReasonInvalidChunk).Inc()
+
+		return nil
+	}
+
+	if !a.reserve(id, len(msg.Data)) {
+		return nil
+	}
+
+	set.chunks[index] = msg.Data
+	set.received++
+	set.bytes += len(msg.Data)
+
+	if set.received < set.count {
+		return nil
+	}
+
+	a.drop(id)
+
+	return set.message(msg)
+}
+
+// message returns the message reassembled from the complete chunk set,
+// with the subject of the last chunk and without the chunk headers.
+func (s *chunkSet) message(last *nats.Msg) *nats.Msg {
+	header := maps.Clone(s.header)
+	header.Del(common.HeaderChunkID)
+	header.Del(common.HeaderChunkIndex)
+	header.Del(common.HeaderChunkCount)
+
+	data := make([]byte, 0, s.bytes)
+	for i := range s.count {
+		data = append(data, s.chunks[i]...)
+	}
+
+	return &nats.Msg{
+		Subject: last.Subject,
+		Reply:   last.Reply,
+		Header:  header,
+		Data:    data,
+	}
+}
+
+// reserve makes room for size more bytes of the message with the given id by dropping
+// the oldest other incomplete messages. It returns false and drops the message itself
+// if it can't fit into the memory cap on its own.
+func (a *assembler) reserve(id string, size int) bool {
+	if a.maxBytes > 0 && a.pending[id].bytes+size > a.maxBytes {
+		a.logger.Warn().Str("chunkId", id).Int("maxBytes", a.maxBytes).
+			Msg("dropping an incomplete message that exceeds the memory cap")
+		a.drop(id)
+		common.MetricMessagesDropped.WithLabelValues(common.DropReasonChunkMemoryCap).Inc()
+
+		return false
+	}
+
+	for a.maxBytes > 0 && a.bytes+size > a.maxBytes {
+		oldest := a.oldest(id)
+		a.logger.Warn().Str("chunkId", oldest).Int("maxBytes", a.maxBytes).
+			Msg("dropping the oldest incomplete message to stay within the memory cap")
+		a.drop(oldest)
+		common.MetricMessagesDropped.WithLabelValues(common.DropReasonChunkMemoryCap).Inc()
+	}
+
+	a.bytes += size
+
+	return true
+}
+
+// expire drops incomplete messages that didn't receive all of their chunks within the timeout.
+func (a *assembler) expire() {
+	if a.timeout <= 0 {
+		return
+	}
+
+	now := a.now()
+	for id, set := range a.pending {
+		if now.Sub(set.started) > a.timeout {
+			a.logger.Warn().Str("chunkId", id).Int("received", set.received).Int("count", set.count).
+				Msg("dropping an incomplete message after the chunk timeout")
+			a.drop(id)
+			common.MetricMessagesDropped.WithLabelValues(common.DropReasonChunkTimeout).Inc()
+		}
+	}
+}
+
+// oldest returns the id of the incomplete message that started first, except the given one.
+func (a *assembler) oldest(except string) string {
+	var (
+		oldestID string
+		started  time.Time
+	)
+
+	for id, set := range a.pending {
+		if id == except {
+			continue
+		}
+
+		if oldestID == "" || set.started.Before(started) {
+			oldestID, started = id, set.started
+		}
+	}
+
+	return oldestID
+}
+
+// drop removes the incomplete message with the given id and releases its memory.
+func (a *assembler) drop(id string) {
+	if set, ok := a.pending[id]; ok {
+		a.bytes -= set.bytes
+		delete(a.pending, id)
+	}
+}
+
+// parseChunkHeaders returns the chunk index and the number of chunks from the message headers.
+// Every chunk carries at least one byte, so messages with more than maxCount chunks are
+// rejected, unless maxCount is 0.
+func parseChunkHeaders(header nats.Header, maxCount int) (int, int, error) {
+	index, err := strconv.Atoi(header.Get(common.HeaderChunkIndex))
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse %s: %w", common.HeaderChunkIndex, ErrInvalidChunk)
+	}
+
+	count, err := strconv.Atoi(header.Get(common.HeaderChunkCount))
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse %s: %w", common.HeaderChunkCount, ErrInvalidChunk)
+	}
+
+	if count < 1 || index < 0 || index >= count || (maxCount > 0 && count > maxCount) {
+		return 0, 0, fmt.Errorf("chunk %d of %d: %w", index, count, ErrInvalidChunk)
+	}
+
+	return index, count, nil
+}
@@ -0,0 +1,140 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/matryer/is"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+)
+
+func newChunk(id string, index, count int, data string) *nats.Msg {
+	msg := nats.NewMsg("foo")
+	msg.Header.Set("Custom", "value")
+	msg.Header.Set(common.HeaderChunkID, id)
+	msg.Header.Set(common.HeaderChunkIndex, strconv.Itoa(index))
+	msg.Header.Set(common.HeaderChunkCount, strconv.Itoa(count))
+	msg.Data = []byte(data)
+
+	return msg
+}
+
+func TestAssembler_Add(t *testing.T) {
+	is := is.New(t)
+
+	logger := zerolog.Nop()
+	a := newAssembler(time.Minute, 0, &logger)
+
+	plain := &nats.Msg{Subject: "foo", Data: []byte("plain")}
+	is.Equal(a.add(plain), plain) // messages without chunk headers pass through
+
+	// chunks of two messages arrive interleaved and out of order
+	is.Equal(a.add(newChunk("a", 1, 3, "bb")), nil)
+	is.Equal(a.add(newChunk("b", 0, 2, "xx")), nil)
+	is.Equal(a.add(newChunk("a", 0, 3, "aa")), nil)
+	is.Equal(a.add(newChunk("a", 1, 3, "bb")), nil) // duplicate is dropped
+
+	got := a.add(newChunk("a", 2, 3, "c"))
+	is.True(got != nil)
+	is.Equal(string(got.Data), "aabbc")
+	is.Equal(got.Header.Get("Custom"), "value")
+	is.Equal(got.Header.Get(common.HeaderChunkID), "")
+
+	got = a.add(newChunk("b", 1, 2, "yy"))
+	is.True(got != nil)
+	is.Equal(string(got.Data), "xxyy")
+
+	is.Equal(len(a.pending), 0)
+	is.Equal(a.bytes, 0)
+}
+
+func TestAssembler_AddMalformed(t *testing.T) {
+	is := is.New(t)
+
+	logger := zerolog.Nop()
+	a := newAssembler(time.Minute, 0, &logger)
+
+	is.Equal(a.add(newChunk("a", 3, 3, "aa")), nil)
+	is.Equal(a.add(newChunk("a", -1, 3, "aa")), nil)
+	is.Equal(len(a.pending), 0)
+
+	// a huge count doesn't allocate anything up front
+	is.Equal(a.add(newChunk("a", 0, math.MaxInt, "aa")), nil)
+	is.Equal(a.pending["a"].received, 1)
+}
+
+func TestAssembler_AddCountExceedsMaxBytes(t *testing.T) {
+	is := is.New(t)
+
+	logger := zerolog.Nop()
+	a := newAssembler(time.Minute, 1024, &logger)
+
+	// every chunk carries at least a byte, so more chunks than maxBytes can't fit
+	is.Equal(a.add(newChunk("a", 0, 1025, "aa")), nil)
+	is.Equal(len(a.pending), 0)
+
+	_, _, err := parseChunkHeaders(newChunk("a", 0, 1<<30, "aa").Header, 1024)
+	is.True(errors.Is(err, ErrInvalidChunk))
+}
+
+func TestAssembler_Timeout(t *testing.T) {
+	is := is.New(t)
+
+	now := time.Now()
+
+	logger := zerolog.Nop()
+	a := newAssembler(time.Minute, 0, &logger)
+	a.now = func() time.Time { return now }
+
+	is.Equal(a.add(newChunk("a", 0, 2, "aa")), nil)
+
+	now = now.Add(2 * time.Minute)
+
+	// the first chunk expired, so the message stays incomplete
+	is.Equal(a.add(newChunk("a", 1, 2, "bb")), nil)
+	is.Equal(a.pending["a"].received, 1)
+	is.Equal(a.bytes, 2)
+}
+
+func TestAssembler_MaxBytes(t *testing.T) {
+	is := is.New(t)
+
+	now := time.Now()
+
+	logger := zerolog.Nop()
+	a := newAssembler(time.Minute, 6, &logger)
+	a.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+
+		return now
+	}
+
+	is.Equal(a.add(newChunk("a", 0, 2, "aaaa")), nil)
+	is.Equal(a.add(newChunk("b", 0, 2, "bbbb")), nil) // drops the oldest message "a"
+	_, ok := a.pending["a"]
+	is.True(!ok)
+	is.Equal(a.bytes, 4)
+
+	is.Equal(a.add(newChunk("b", 1, 2, "bbb")), nil) // message "b" doesn't fit on its own
+	is.Equal(len(a.pending), 0)
+	is.Equal(a.bytes, 0)
+}
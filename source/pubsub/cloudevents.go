@@ -0,0 +1,120 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/nats-io/nats.go"
+)
+
+// ErrInvalidCloudEvent occurs when a message in structured mode isn't a valid CloudEvent.
+var ErrInvalidCloudEvent = errors.New("invalid CloudEvent")
+
+// parseCloudEvent adds the attributes of a CloudEvent to the metadata and returns the event data.
+// Events in structured mode are recognized by their content type, events in binary mode by the
+// ce-specversion header. The data of other messages is returned as it is.
+func parseCloudEvent(header nats.Header, data []byte, metadata opencdc.Metadata) ([]byte, error) {
+	if common.IsCloudEventsContentType(common.HeaderValue(header, common.HeaderContentType)) {
+		return parseStructuredCloudEvent(data, metadata)
+	}
+
+	if common.HeaderValue(header, common.CloudEventsHeaderPrefix+common.CloudEventsAttrSpecVersion) != "" {
+		parseBinaryCloudEvent(header, metadata)
+	}
+
+	return data, nil
+}
+
+// parseBinaryCloudEvent adds the attributes of an event carried in ce- headers to the metadata.
+func parseBinaryCloudEvent(header nats.Header, metadata opencdc.Metadata) {
+	for name, values := range header {
+		attr, ok := strings.CutPrefix(strings.ToLower(name), common.CloudEventsHeaderPrefix)
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		metadata[common.CloudEventsMetadataPrefix+attr] = values[0]
+	}
+
+	if contentType := common.HeaderValue(header, common.HeaderContentType); contentType != "" {
+		metadata[common.CloudEventsMetadataPrefix+common.CloudEventsAttrDataContentType] = contentType
+	}
+}
+
+// parseStructuredCloudEvent adds the attributes of an event in a JSON envelope to the metadata
+// and returns the event data.
+func parseStructuredCloudEvent(data []byte, metadata opencdc.Metadata) ([]byte, error) {
+	var event map[string]json.RawMessage
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCloudEvent, err)
+	}
+
+	if _, ok := event[common.CloudEventsAttrSpecVersion]; !ok {
+		return nil, fmt.Errorf("%w: no %s attribute", ErrInvalidCloudEvent, common.CloudEventsAttrSpecVersion)
+	}
+
+	for attr, value := range event {
+		if attr == "data" || attr == "data_base64" {
+			continue
+		}
+
+		metadata[common.CloudEventsMetadataPrefix+attr] = attributeValue(value)
+	}
+
+	if value, ok := event["data_base64"]; ok {
+		var encoded string
+		if err := json.Unmarshal(value, &encoded); err != nil {
+			return nil, fmt.Errorf("%w: data_base64 isn't a string", ErrInvalidCloudEvent)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: decode data_base64: %w", ErrInvalidCloudEvent, err)
+		}
+
+		return decoded, nil
+	}
+
+	value, ok := event["data"]
+	if !ok {
+		return nil, nil
+	}
+
+	// JSON data is kept as JSON, other data is a JSON string
+	var text string
+	if !common.IsJSONContentType(metadata[common.CloudEventsMetadataPrefix+common.CloudEventsAttrDataContentType]) &&
+		json.Unmarshal(value, &text) == nil {
+		return []byte(text), nil
+	}
+
+	return value, nil
+}
+
+// attributeValue returns the value of a string attribute, or the JSON of other attributes.
+func attributeValue(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+
+	return string(value)
+}
@@ -0,0 +1,143 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	"github.com/nats-io/nats.go"
+)
+
+func TestParseCloudEvent(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       nats.Header
+		data         string
+		wantData     string
+		wantMetadata opencdc.Metadata
+		wantErr      error
+	}{
+		{
+			name: "binary mode",
+			header: nats.Header{
+				"ce-specversion": {"1.0"},
+				"ce-id":          {"1"},
+				"Ce-Source":      {"/orders"},
+				"ce-type":        {"order.created"},
+				"ce-tenant":      {"acme"},
+				"Content-Type":   {"application/json"},
+				"Other":          {"value"},
+			},
+			data:     `{"id":1}`,
+			wantData: `{"id":1}`,
+			wantMetadata: opencdc.Metadata{
+				"cloudevents.specversion":     "1.0",
+				"cloudevents.id":              "1",
+				"cloudevents.source":          "/orders",
+				"cloudevents.type":            "order.created",
+				"cloudevents.tenant":          "acme",
+				"cloudevents.datacontenttype": "application/json",
+			},
+		},
+		{
+			name:   "structured mode, JSON data",
+			header: nats.Header{"Content-Type": {"application/cloudevents+json; charset=utf-8"}},
+			data: `{"specversion":"1.0","id":"1","source":"/orders","type":"order.created",` +
+				`"datacontenttype":"application/json","sequence":7,"data":{"id":1}}`,
+			wantData: `{"id":1}`,
+			wantMetadata: opencdc.Metadata{
+				"cloudevents.specversion":     "1.0",
+				"cloudevents.id":              "1",
+				"cloudevents.source":          "/orders",
+				"cloudevents.type":            "order.created",
+				"cloudevents.datacontenttype": "application/json",
+				"cloudevents.sequence":        "7",
+			},
+		},
+		{
+			name:   "structured mode, text data",
+			header: nats.Header{"Content-Type": {"application/cloudevents+json"}},
+			data: `{"specversion":"1.0","id":"1","source":"/orders","type":"order.created",` +
+				`"datacontenttype":"text/plain","data":"hello"}`,
+			wantData: "hello",
+			wantMetadata: opencdc.Metadata{
+				"cloudevents.specversion":     "1.0",
+				"cloudevents.id":              "1",
+				"cloudevents.source":          "/orders",
+				"cloudevents.type":            "order.created",
+				"cloudevents.datacontenttype": "text/plain",
+			},
+		},
+		{
+			name:     "structured mode, base64 data",
+			header:   nats.Header{"Content-Type": {"application/cloudevents+json"}},
+			data:     `{"specversion":"1.0","id":"1","source":"/orders","type":"order.created","data_base64":"AAE="}`,
+			wantData: "\x00\x01",
+			wantMetadata: opencdc.Metadata{
+				"cloudevents.specversion": "1.0",
+				"cloudevents.id":          "1",
+				"cloudevents.source":      "/orders",
+				"cloudevents.type":        "order.created",
+			},
+		},
+		{
+			name:         "not a CloudEvent",
+			header:       nats.Header{"ce-id": {"1"}},
+			data:         "plain",
+			wantData:     "plain",
+			wantMetadata: opencdc.Metadata{},
+		},
+		{
+			name:    "fail, structured mode, invalid JSON",
+			header:  nats.Header{"Content-Type": {"application/cloudevents+json"}},
+			data:    `{"specversion":`,
+			wantErr: ErrInvalidCloudEvent,
+		},
+		{
+			name:    "fail, structured mode, no specversion",
+			header:  nats.Header{"Content-Type": {"application/cloudevents+json"}},
+			data:    `{"id":"1","data":{}}`,
+			wantErr: ErrInvalidCloudEvent,
+		},
+		{
+			name:    "fail, structured mode, invalid base64 data",
+			header:  nats.Header{"Content-Type": {"application/cloudevents+json"}},
+			data:    `{"specversion":"1.0","data_base64":"!"}`,
+			wantErr: ErrInvalidCloudEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			metadata := opencdc.Metadata{}
+
+			data, err := parseCloudEvent(tt.header, []byte(tt.data), metadata)
+			if tt.wantErr != nil {
+				is.True(errors.Is(err, tt.wantErr))
+
+				return
+			}
+			is.NoErr(err)
+
+			is.Equal(string(data), tt.wantData)
+			is.Equal(metadata, tt.wantMetadata)
+		})
+	}
+}
@@ -16,71 +16,236 @@ package pubsub
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
 	"github.com/conduitio/conduit-commons/opencdc"
 	sdk "github.com/conduitio/conduit-connector-sdk"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
 )
 
+// ErrNoKeyProvider occurs when an encrypted message arrives, but no encryption key provider is configured.
+var ErrNoKeyProvider = errors.New("message is encrypted, but no encryption key provider is configured")
+
 // Iterator is a iterator for Pub/Sub communication model.
 // It receives any new message from NATS.
 type Iterator struct {
-	conn         *nats.Conn
+	conn         *common.Conn
+	subject      string
 	messages     chan *nats.Msg
+	errC         chan error
 	subscription *nats.Subscription
+	assembler    *assembler
+	expiry       *time.Ticker
+	keyProvider  common.KeyProvider
+	cloudEvents  bool
+	inferrer     *schemaInferrer
+	// maxDecompressedBytes is the maximum size of a decompressed payload, zero means no limit.
+	maxDecompressedBytes int
+	// pendingErr is the error of a message NextN failed to convert after it collected
+	// other records. It's returned by the next call to Next or NextN.
+	pendingErr error
 }
 
 // IteratorParams contains incoming params for the NewIterator function.
 type IteratorParams struct {
-	Conn       *nats.Conn
+	Conn       *common.Conn
 	BufferSize int
 	Subject    string
+	// ChunkTimeout is the maximum time to wait for all the chunks of a split message.
+	ChunkTimeout time.Duration
+	// ChunkMaxBytes is the maximum number of bytes held by incomplete split messages.
+	ChunkMaxBytes int
+	// MaxDecompressedBytes is the maximum number of bytes of a decompressed payload.
+	// Zero means no limit.
+	MaxDecompressedBytes int
+	// KeyProvider provides the keys used to decrypt encrypted messages.
+	KeyProvider common.KeyProvider
+	// CloudEvents parses CloudEvents messages into the record metadata and payload.
+	CloudEvents bool
+	// InferSchema decodes payloads that are JSON objects into structured data, and registers
+	// and attaches the schema inferred from them.
+	InferSchema bool
+	// SchemaSubject is the subject inferred schemas are registered under. If empty, it's the
+	// subject the message was published to followed by ".payload".
+	SchemaSubject string
+	// Logger is used to report dropped chunks and payloads whose schema can't be inferred.
+	Logger *zerolog.Logger
 }
 
 // NewIterator creates new instance of the Iterator.
 func NewIterator(params IteratorParams) (*Iterator, error) {
+	logger := params.Logger
+	if logger == nil {
+		nop := zerolog.Nop()
+		logger = &nop
+	}
+
 	messages := make(chan *nats.Msg, params.BufferSize)
+	errC := make(chan error, 1)
+
+	var (
+		subscription    atomic.Pointer[nats.Subscription]
+		reportedDropped int
+	)
+
+	// register an error handler for async errors,
+	// the Iterator listens to them within the Next method and propagates the error if it occurs.
+	// Errors of other subscriptions on a shared connection are ignored.
+	params.Conn.SetErrorHandler(func(_ *nats.Conn, sub *nats.Subscription, err error) {
+		if sub != nil && sub != subscription.Load() {
+			return
+		}
 
-	subscription, err := params.Conn.ChanSubscribe(params.Subject, messages)
+		if errors.Is(err, nats.ErrSlowConsumer) {
+			countSlowConsumer(sub, &reportedDropped)
+		}
+
+		select {
+		case errC <- err:
+		default:
+			// an error is already pending, the Iterator will fail on it anyway
+		}
+	})
+
+	sub, err := params.Conn.ChanSubscribe(params.Subject, messages)
 	if err != nil {
 		return nil, fmt.Errorf("chan subscribe: %w", err)
 	}
 
+	subscription.Store(sub)
+
+	// incomplete split messages expire even if no more chunks arrive
+	var expiry *time.Ticker
+	if params.ChunkTimeout > 0 {
+		expiry = time.NewTicker(params.ChunkTimeout)
+	}
+
+	var inferrer *schemaInferrer
+	if params.InferSchema {
+		inferrer = newSchemaInferrer(params.SchemaSubject, logger)
+	}
+
 	return &Iterator{
-		conn:         params.Conn,
-		messages:     messages,
-		subscription: subscription,
+		conn:                 params.Conn,
+		subject:              params.Subject,
+		messages:             messages,
+		errC:                 errC,
+		subscription:         sub,
+		assembler:            newAssembler(params.ChunkTimeout, params.ChunkMaxBytes, logger),
+		expiry:               expiry,
+		keyProvider:          params.KeyProvider,
+		cloudEvents:          params.CloudEvents,
+		inferrer:             inferrer,
+		maxDecompressedBytes: params.MaxDecompressedBytes,
 	}, nil
 }
 
-// HasNext checks is the iterator has messages.
-func (i *Iterator) HasNext() bool {
-	return len(i.messages) > 0
-}
-
 // Next returns the next record from the underlying messages channel.
+// It blocks until a message arrives, an async error occurs or the context is done.
+// Chunks of split messages are collected until the whole message is reassembled.
 func (i *Iterator) Next(ctx context.Context) (opencdc.Record, error) {
-	select {
-	case msg := <-i.messages:
-		return i.messageToRecord(msg)
+	if err := i.pendingErr; err != nil {
+		i.pendingErr = nil
+
+		return opencdc.Record{}, err
+	}
 
-	case <-ctx.Done():
-		return opencdc.Record{}, ctx.Err()
+	var expiryC <-chan time.Time
+	if i.expiry != nil {
+		expiryC = i.expiry.C
 	}
+
+	for {
+		select {
+		case msg := <-i.messages:
+			if msg = i.assemble(msg); msg == nil {
+				// wait for the remaining chunks
+				continue
+			}
+
+			return i.messageToRecord(ctx, msg)
+
+		case <-expiryC:
+			if i.assembler != nil {
+				i.assembler.expire()
+			}
+
+		case err := <-i.errC:
+			return opencdc.Record{}, fmt.Errorf("got an async error: %w", err)
+
+		case <-ctx.Done():
+			return opencdc.Record{}, ctx.Err()
+		}
+	}
+}
+
+// NextN returns up to n records from the underlying messages channel.
+// It blocks until the first message arrives, an async error occurs or the context is done,
+// then drains messages that are already buffered without waiting for new ones.
+// If a drained message can't be converted, the records collected before it are returned
+// and the error is returned by the next call.
+func (i *Iterator) NextN(ctx context.Context, n int) ([]opencdc.Record, error) {
+	record, err := i.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]opencdc.Record, 0, max(n, 1))
+	records = append(records, record)
+
+	for len(records) < n {
+		select {
+		case msg := <-i.messages:
+			if msg = i.assemble(msg); msg == nil {
+				continue
+			}
+
+			record, err := i.messageToRecord(ctx, msg)
+			if err != nil {
+				// return the records collected so far, the error is returned by the next call
+				i.pendingErr = err
+
+				return records, nil //nolint:nilerr // the error is kept for the next call
+			}
+
+			records = append(records, record)
+
+		default:
+			return records, nil
+		}
+	}
+
+	return records, nil
 }
 
 // Stop stops the Iterator, unsubscribes from a subject.
+// The connection is closed even if unsubscribing fails.
 func (i *Iterator) Stop() (err error) {
+	if i.expiry != nil {
+		i.expiry.Stop()
+	}
+
 	if i.subscription != nil {
 		if err = i.subscription.Unsubscribe(); err != nil {
+			// the subscription may still deliver messages, so the channel stays open,
+			// but the lease of a shared connection is released
+			if i.conn != nil {
+				i.conn.Close()
+			}
+
 			return fmt.Errorf("unsubscribe: %w", err)
 		}
 	}
 
 	close(i.messages)
+	common.MetricPendingMessages.DeleteLabelValues(i.subject)
 
 	if i.conn != nil {
 		i.conn.Close()
@@ -89,17 +254,106 @@ func (i *Iterator) Stop() (err error) {
 	return nil
 }
 
+// assemble counts the received message and passes it through the assembler, if there's one.
+// It returns nil if the message is a chunk of an incomplete message.
+func (i *Iterator) assemble(msg *nats.Msg) *nats.Msg {
+	common.MetricMessagesReceived.WithLabelValues(i.subject).Inc()
+	common.MetricBytesReceived.WithLabelValues(i.subject).Add(float64(len(msg.Data)))
+	common.MetricPendingMessages.WithLabelValues(i.subject).Set(float64(len(i.messages)))
+
+	if i.assembler == nil {
+		return msg
+	}
+
+	return i.assembler.add(msg)
+}
+
 // messageToRecord converts a *nats.Msg to a opencdc.Record.
-func (i *Iterator) messageToRecord(msg *nats.Msg) (opencdc.Record, error) {
+func (i *Iterator) messageToRecord(ctx context.Context, msg *nats.Msg) (opencdc.Record, error) {
 	position, err := i.getPosition()
 	if err != nil {
 		return opencdc.Record{}, fmt.Errorf("get position: %w", err)
 	}
 
+	data, err := i.decrypt(msg)
+	if err != nil {
+		return opencdc.Record{}, fmt.Errorf("decrypt payload: %w", err)
+	}
+
+	// other encodings are set by other producers and left for the pipeline to handle
+	if encoding := msg.Header.Get(common.HeaderContentEncoding); common.IsCompressionCodec(encoding) {
+		data, err = common.Decompress(encoding, data, i.maxDecompressedBytes)
+		if err != nil {
+			return opencdc.Record{}, fmt.Errorf("decompress payload: %w", err)
+		}
+	}
+
 	metadata := make(opencdc.Metadata)
 	metadata.SetCreatedAt(time.Now())
+	common.ExtractTraceContext(ctx, msg.Header, metadata)
+
+	if i.cloudEvents {
+		data, err = parseCloudEvent(msg.Header, data, metadata)
+		if err != nil {
+			return opencdc.Record{}, fmt.Errorf("parse CloudEvent: %w", err)
+		}
+	}
+
+	payload, err := decodePayload(ctx, msg.Header, data, metadata)
+	if err != nil {
+		return opencdc.Record{}, fmt.Errorf("decode payload: %w", err)
+	}
+
+	if i.inferrer != nil {
+		payload, err = i.inferrer.infer(ctx, msg.Subject, payload, metadata)
+		if err != nil {
+			return opencdc.Record{}, err
+		}
+	}
+
+	return sdk.Util.Source.NewRecordCreate(position, metadata, nil, payload), nil
+}
+
+// decrypt returns the decrypted message payload, or the payload as is if the message isn't encrypted.
+func (i *Iterator) decrypt(msg *nats.Msg) ([]byte, error) {
+	keyID := msg.Header.Get(common.HeaderEncryptionKeyID)
+	if keyID == "" {
+		return msg.Data, nil
+	}
+
+	if i.keyProvider == nil {
+		return nil, ErrNoKeyProvider
+	}
+
+	dataKey, err := base64.StdEncoding.DecodeString(msg.Header.Get(common.HeaderEncryptionDataKey))
+	if err != nil {
+		return nil, fmt.Errorf("decode data key: %w", err)
+	}
+
+	data, err := common.Decrypt(i.keyProvider, keyID, msg.Data, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt with key %q: %w", keyID, err)
+	}
+
+	return data, nil
+}
+
+// countSlowConsumer counts a slow consumer event of the subscription and the
+// messages it dropped since the last reported count.
+func countSlowConsumer(sub *nats.Subscription, reported *int) {
+	common.MetricSlowConsumerEvents.Inc()
+
+	if sub == nil {
+		return
+	}
+
+	dropped, err := sub.Dropped()
+	if err != nil || dropped <= *reported {
+		return
+	}
 
-	return sdk.Util.Source.NewRecordCreate(position, metadata, nil, opencdc.RawData(msg.Data)), nil
+	common.MetricMessagesDropped.WithLabelValues(common.DropReasonSlowConsumer).Add(float64(dropped - *reported))
+	*reported = dropped
 }
 
 // getPosition returns the current iterator position.
@@ -15,47 +15,81 @@
 package pubsub
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
 	"github.com/conduitio/conduit-commons/opencdc"
 	"github.com/google/uuid"
+	"github.com/klauspost/compress/snappy"
+	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
 )
 
-func TestPubSubIterator_HasNext(t *testing.T) {
+func TestPubSubIterator_Next(t *testing.T) {
 	type fields struct {
 		messages chan *nats.Msg
+		errC     chan error
 	}
 
 	tests := []struct {
 		name     string
 		fields   fields
-		fillFunc func(chan *nats.Msg)
-		want     bool
+		timeout  time.Duration
+		fillFunc func(fields)
+		want     opencdc.Record
+		wantErr  error
 	}{
 		{
-			name: "true, one message",
+			name: "success, one message",
 			fields: fields{
 				messages: make(chan *nats.Msg, 1),
+				errC:     make(chan error, 1),
 			},
-			fillFunc: func(c chan *nats.Msg) {
-				c <- &nats.Msg{
+			fillFunc: func(f fields) {
+				f.messages <- &nats.Msg{
 					Subject: "foo",
 					Data:    []byte(`"name": "bob"`),
 				}
 			},
-			want: true,
+			want: opencdc.Record{
+				Operation: opencdc.OperationCreate,
+				Payload: opencdc.Change{
+					After: opencdc.RawData([]byte(`"name": "bob"`)),
+				},
+			},
+			wantErr: nil,
 		},
 		{
-			name: "false, no messages",
+			name: "fail, async error",
 			fields: fields{
-				messages: make(chan *nats.Msg),
+				messages: make(chan *nats.Msg, 1),
+				errC:     make(chan error, 1),
 			},
+			fillFunc: func(f fields) {
+				f.errC <- nats.ErrSlowConsumer
+			},
+			want:    opencdc.Record{},
+			wantErr: nats.ErrSlowConsumer,
+		},
+		{
+			name: "fail, no messages, context deadline",
+			fields: fields{
+				messages: make(chan *nats.Msg, 1),
+				errC:     make(chan error, 1),
+			},
+			timeout:  20 * time.Millisecond,
 			fillFunc: nil,
-			want:     false,
+			want:     opencdc.Record{},
+			wantErr:  context.DeadlineExceeded,
 		},
 	}
 
@@ -63,120 +97,286 @@ func TestPubSubIterator_HasNext(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			i := &Iterator{
 				messages: tt.fields.messages,
+				errC:     tt.fields.errC,
 			}
 
 			if tt.fillFunc != nil {
-				tt.fillFunc(tt.fields.messages)
+				tt.fillFunc(tt.fields)
 			}
 
-			if got := i.HasNext(); got != tt.want {
-				t.Errorf("PubSubIterator.HasNext() = %v, want %v", got, tt.want)
+			ctx := context.Background()
+			if tt.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			got, err := i.Next(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PubSubIterator.Next() error = %v, wantErr %v", err, tt.wantErr)
 
 				return
 			}
+
+			// we don't care about these fields
+			tt.want.Metadata = got.Metadata
+			tt.want.Position = got.Position
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PubSubIterator.Next() = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
 
-func TestPubSubIterator_Next(t *testing.T) {
-	type fields struct {
-		messages chan *nats.Msg
-	}
-
+func TestPubSubIterator_NextN(t *testing.T) {
 	tests := []struct {
 		name     string
-		fields   fields
+		buffered int
+		n        int
 		timeout  time.Duration
-		fillFunc func(chan *nats.Msg)
-		want     opencdc.Record
-		wantErr  bool
+		wantLen  int
+		wantErr  error
 	}{
 		{
-			name: "success, one message",
-			fields: fields{
-				messages: make(chan *nats.Msg, 1),
-			},
-			fillFunc: func(c chan *nats.Msg) {
-				c <- &nats.Msg{
-					Subject: "foo",
-					Data:    []byte(`"name": "bob"`),
-				}
-			},
-			want: opencdc.Record{
-				Operation: opencdc.OperationCreate,
-				Payload: opencdc.Change{
-					After: opencdc.RawData([]byte(`"name": "bob"`)),
-				},
-			},
-			wantErr: false,
+			name:     "success, fewer messages than n",
+			buffered: 3,
+			n:        5,
+			wantLen:  3,
+			wantErr:  nil,
 		},
 		{
-			name: "success, no messages, skip",
-			fields: fields{
-				messages: make(chan *nats.Msg, 1),
-			},
-			fillFunc: nil,
-			want:     opencdc.Record{},
-			wantErr:  false,
+			name:     "success, more messages than n",
+			buffered: 5,
+			n:        2,
+			wantLen:  2,
+			wantErr:  nil,
 		},
 		{
-			name: "success, no messages, context deadline",
-			fields: fields{
-				messages: make(chan *nats.Msg, 1),
-			},
+			name:     "success, non-positive n returns one message",
+			buffered: 3,
+			n:        0,
+			wantLen:  1,
+			wantErr:  nil,
+		},
+		{
+			name:     "fail, no messages, context deadline",
+			buffered: 0,
+			n:        5,
 			timeout:  20 * time.Millisecond,
-			fillFunc: nil,
-			want:     opencdc.Record{},
-			wantErr:  false,
+			wantLen:  0,
+			wantErr:  context.DeadlineExceeded,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			i := &Iterator{
-				messages: tt.fields.messages,
+				messages: make(chan *nats.Msg, tt.buffered+1),
+				errC:     make(chan error, 1),
 			}
 
-			if tt.fillFunc != nil {
-				tt.fillFunc(tt.fields.messages)
+			for n := 0; n < tt.buffered; n++ {
+				i.messages <- &nats.Msg{
+					Subject: "foo",
+					Data:    []byte(`"name": "bob"`),
+				}
 			}
 
-			if !i.HasNext() {
+			ctx := context.Background()
+			if tt.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			got, err := i.NextN(ctx, tt.n)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PubSubIterator.NextN() error = %v, wantErr %v", err, tt.wantErr)
+
 				return
 			}
 
-			for i.HasNext() {
-				var ctx context.Context
-				var cancel context.CancelFunc
+			if len(got) != tt.wantLen {
+				t.Errorf("len(PubSubIterator.NextN()) = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
 
-				if tt.timeout != 0 {
-					ctx, cancel = context.WithTimeout(context.Background(), tt.timeout)
-				} else {
-					ctx = context.Background()
-				}
+func TestPubSubIterator_NextN_ConversionError(t *testing.T) {
+	is := is.New(t)
 
-				got, err := i.Next(ctx)
+	i := &Iterator{
+		messages: make(chan *nats.Msg, 4),
+		errC:     make(chan error, 1),
+	}
 
-				if cancel != nil {
-					cancel()
-				}
+	i.messages <- &nats.Msg{Subject: "foo", Data: []byte("first")}
+	i.messages <- &nats.Msg{Subject: "foo", Data: []byte("second")}
+	i.messages <- &nats.Msg{
+		Subject: "foo",
+		Header:  nats.Header{common.HeaderContentEncoding: []string{common.CompressionSnappy}},
+		Data:    []byte("not compressed"),
+	}
+	i.messages <- &nats.Msg{Subject: "foo", Data: []byte("third")}
+
+	// the records collected before the failing message are returned
+	got, err := i.NextN(context.Background(), 10)
+	is.NoErr(err)
+	is.Equal(len(got), 2)
+	is.Equal(got[0].Payload.After, opencdc.RawData("first"))
+	is.Equal(got[1].Payload.After, opencdc.RawData("second"))
+
+	// the error is returned by the next call
+	_, err = i.NextN(context.Background(), 10)
+	is.True(err != nil)
+
+	got, err = i.NextN(context.Background(), 10)
+	is.NoErr(err)
+	is.Equal(len(got), 1)
+	is.Equal(got[0].Payload.After, opencdc.RawData("third"))
+}
 
-				if (err != nil) != tt.wantErr {
-					t.Errorf("PubSubIterator.Next() error = %v, wantErr %v", err, tt.wantErr)
+func TestPubSubIterator_Metrics(t *testing.T) {
+	is := is.New(t)
 
-					return
-				}
+	logger := zerolog.Nop()
 
-				// we don't care about these fields
-				tt.want.Metadata = got.Metadata
-				tt.want.Position = got.Position
+	i := &Iterator{
+		subject:   "metrics.>",
+		messages:  make(chan *nats.Msg, 4),
+		errC:      make(chan error, 1),
+		assembler: newAssembler(time.Minute, 0, &logger),
+	}
 
-				if !reflect.DeepEqual(got, tt.want) {
-					t.Errorf("PubSubIterator.Next() = %v, want %v", got, tt.want)
-				}
-			}
-		})
+	received := common.MetricMessagesReceived.WithLabelValues("metrics.>")
+	receivedBytes := common.MetricBytesReceived.WithLabelValues("metrics.>")
+	pending := common.MetricPendingMessages.WithLabelValues("metrics.>")
+	dropped := common.MetricMessagesDropped.WithLabelValues(common.DropReasonInvalidChunk)
+
+	receivedBefore, bytesBefore := testutil.ToFloat64(received), testutil.ToFloat64(receivedBytes)
+	droppedBefore := testutil.ToFloat64(dropped)
+
+	// the malformed chunk is received, but dropped
+	i.messages <- newChunk("a", 2, 2, "xx")
+	i.messages <- &nats.Msg{Subject: "metrics.a", Data: []byte("a")}
+	i.messages <- &nats.Msg{Subject: "metrics.b", Data: []byte("bb")}
+
+	_, err := i.Next(context.Background())
+	is.NoErr(err)
+	is.Equal(testutil.ToFloat64(pending), 1.0)
+
+	_, err = i.Next(context.Background())
+	is.NoErr(err)
+	is.Equal(testutil.ToFloat64(pending), 0.0)
+
+	is.Equal(testutil.ToFloat64(received)-receivedBefore, 3.0)
+	is.Equal(testutil.ToFloat64(receivedBytes)-bytesBefore, 5.0)
+	is.Equal(testutil.ToFloat64(dropped)-droppedBefore, 1.0)
+
+	// the pending messages of a stopped iterator aren't reported
+	is.NoErr(i.Stop())
+	is.True(!common.MetricPendingMessages.DeleteLabelValues("metrics.>"))
+}
+
+func TestPubSubIterator_NextExpiresChunks(t *testing.T) {
+	is := is.New(t)
+
+	logger := zerolog.Nop()
+
+	i := &Iterator{
+		subject:   "expiry",
+		messages:  make(chan *nats.Msg, 1),
+		errC:      make(chan error, 1),
+		assembler: newAssembler(50*time.Millisecond, 0, &logger),
+		expiry:    time.NewTicker(50 * time.Millisecond),
 	}
+
+	defer i.expiry.Stop()
+
+	i.messages <- newChunk("a", 0, 2, "xx")
+
+	// no more messages arrive, the incomplete message expires while Next waits
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	_, err := i.Next(ctx)
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	is.Equal(len(i.assembler.pending), 0)
+	is.Equal(i.assembler.bytes, 0)
+}
+
+func BenchmarkPubSubIterator_Next(b *testing.B) {
+	i := &Iterator{
+		messages: make(chan *nats.Msg),
+		errC:     make(chan error, 1),
+	}
+
+	msg := &nats.Msg{
+		Subject: "foo",
+		Data:    []byte(`"name": "bob"`),
+	}
+
+	go func() {
+		for n := 0; n < b.N; n++ {
+			i.messages <- msg
+		}
+	}()
+
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := i.Next(ctx); err != nil {
+			b.Fatalf("PubSubIterator.Next() error = %v", err)
+		}
+	}
+}
+
+// staticKeyProvider is a common.KeyProvider backed by a map.
+type staticKeyProvider map[string][]byte
+
+func (p staticKeyProvider) Key(id string) ([]byte, error) {
+	key, ok := p[id]
+	if !ok {
+		return nil, common.ErrKeyNotFound
+	}
+
+	return key, nil
+}
+
+func TestPubSubIterator_StopReleasesSharedConnection(t *testing.T) {
+	is := is.New(t)
+
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	is.NoErr(err)
+
+	go srv.Start()
+
+	is.True(srv.ReadyForConnections(5 * time.Second))
+	t.Cleanup(srv.Shutdown)
+
+	cfg := common.Config{URLs: []string{srv.ClientURL()}, Subject: "foo", SharedConnection: true}
+
+	conn, err := cfg.Connect(context.Background())
+	is.NoErr(err)
+
+	other, err := cfg.Connect(context.Background())
+	is.NoErr(err)
+
+	defer other.Close()
+
+	i, err := NewIterator(IteratorParams{Conn: conn, BufferSize: 64, Subject: "foo"})
+	is.NoErr(err)
+
+	// unsubscribing fails, the lease is released anyway
+	is.NoErr(i.subscription.Unsubscribe())
+	is.True(i.Stop() != nil)
+
+	// so the other connector's lease is the last one and closes the connection
+	other.Close()
+	is.True(other.IsClosed())
 }
 
 func TestPubSubIterator_messageToRecord(t *testing.T) {
@@ -184,11 +384,31 @@ func TestPubSubIterator_messageToRecord(t *testing.T) {
 		msg *nats.Msg
 	}
 
+	keyProvider := staticKeyProvider{"k1": bytes.Repeat([]byte{1}, 32)}
+
+	encrypted, dataKey, err := common.Encrypt(keyProvider, "k1", snappy.Encode(nil, []byte("sample")))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	encryptedHeader := nats.Header{
+		common.HeaderContentEncoding:   []string{common.CompressionSnappy},
+		common.HeaderEncryptionKeyID:   []string{"k1"},
+		common.HeaderEncryptionDataKey: []string{base64.StdEncoding.EncodeToString(dataKey)},
+	}
+
+	avroHeader, avroData := encodeWithSchema(t, "foo.payload",
+		`{"type":"record","name":"sample","fields":[{"name":"id","type":"long"}]}`,
+		opencdc.StructuredData{"id": int64(1)})
+
 	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
-		want    opencdc.Record
+		name        string
+		args        args
+		keyProvider common.KeyProvider
+		// maxDecompressedBytes is the maximum size of decompressed payloads
+		maxDecompressedBytes int
+		wantErr              bool
+		want                 opencdc.Record
 	}{
 		{
 			name: "success",
@@ -206,6 +426,113 @@ func TestPubSubIterator_messageToRecord(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "success, data encoded with a schema",
+			args: args{
+				msg: &nats.Msg{
+					Subject: "foo",
+					Header:  avroHeader,
+					Data:    avroData,
+				},
+			},
+			wantErr: false,
+			want: opencdc.Record{
+				Operation: opencdc.OperationCreate,
+				Payload: opencdc.Change{
+					After: opencdc.StructuredData{"id": int64(1)},
+				},
+			},
+		},
+		{
+			name: "success, compressed data",
+			args: args{
+				msg: &nats.Msg{
+					Subject: "foo",
+					Header:  nats.Header{common.HeaderContentEncoding: []string{common.CompressionSnappy}},
+					Data:    snappy.Encode(nil, []byte("sample")),
+				},
+			},
+			wantErr: false,
+			want: opencdc.Record{
+				Operation: opencdc.OperationCreate,
+				Payload: opencdc.Change{
+					After: opencdc.RawData([]byte("sample")),
+				},
+			},
+		},
+		{
+			name: "success, other encoding passed through",
+			args: args{
+				msg: &nats.Msg{
+					Subject: "foo",
+					Header:  nats.Header{common.HeaderContentEncoding: []string{"br"}},
+					Data:    []byte("sample"),
+				},
+			},
+			wantErr: false,
+			want: opencdc.Record{
+				Operation: opencdc.OperationCreate,
+				Payload: opencdc.Change{
+					After: opencdc.RawData([]byte("sample")),
+				},
+			},
+		},
+		{
+			name: "fail, decompressed data too large",
+			args: args{
+				msg: &nats.Msg{
+					Subject: "foo",
+					Header:  nats.Header{common.HeaderContentEncoding: []string{common.CompressionSnappy}},
+					Data:    snappy.Encode(nil, []byte("sample")),
+				},
+			},
+			maxDecompressedBytes: 5,
+			wantErr:              true,
+		},
+		{
+			name: "success, encrypted data",
+			args: args{
+				msg: &nats.Msg{
+					Subject: "foo",
+					Header:  encryptedHeader,
+					Data:    encrypted,
+				},
+			},
+			keyProvider: keyProvider,
+			wantErr:     false,
+			want: opencdc.Record{
+				Operation: opencdc.OperationCreate,
+				Payload: opencdc.Change{
+					After: opencdc.RawData([]byte("sample")),
+				},
+			},
+		},
+		{
+			name: "fail, encrypted data without key provider",
+			args: args{
+				msg: &nats.Msg{
+					Subject: "foo",
+					Header:  encryptedHeader,
+					Data:    encrypted,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, unknown key id",
+			args: args{
+				msg: &nats.Msg{
+					Subject: "foo",
+					Header: nats.Header{
+						common.HeaderEncryptionKeyID:   []string{"k2"},
+						common.HeaderEncryptionDataKey: []string{base64.StdEncoding.EncodeToString(dataKey)},
+					},
+					Data: encrypted,
+				},
+			},
+			keyProvider: keyProvider,
+			wantErr:     true,
+		},
 		{
 			name: "success, nil data",
 			args: args{
@@ -225,15 +552,19 @@ func TestPubSubIterator_messageToRecord(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			i := &Iterator{}
+			i := &Iterator{keyProvider: tt.keyProvider, maxDecompressedBytes: tt.maxDecompressedBytes}
 
-			got, err := i.messageToRecord(tt.args.msg)
+			got, err := i.messageToRecord(context.Background(), tt.args.msg)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("PubSubIterator.messageToRecord() error = %v, wantErr %v", err, tt.wantErr)
 
 				return
 			}
 
+			if tt.wantErr {
+				return
+			}
+
 			// we don't care about time
 			tt.want.Metadata = got.Metadata
 
@@ -253,3 +584,18 @@ func TestPubSubIterator_messageToRecord(t *testing.T) {
 		})
 	}
 }
+
+func TestPubSubIterator_messageToRecord_TraceContext(t *testing.T) {
+	is := is.New(t)
+
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	got, err := (&Iterator{}).messageToRecord(context.Background(), &nats.Msg{
+		Subject: "foo",
+		Header:  nats.Header{"traceparent": {traceParent}, "tracestate": {"vendor=value"}},
+		Data:    []byte("sample"),
+	})
+	is.NoErr(err)
+	is.Equal(got.Metadata[common.MetadataTraceParent], traceParent)
+	is.Equal(got.Metadata[common.MetadataTraceState], "vendor=value")
+}
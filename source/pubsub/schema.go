@@ -0,0 +1,192 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+)
+
+// ErrInvalidSchemaVersion occurs when the schema version header of a message isn't a positive number.
+var ErrInvalidSchemaVersion = errors.New("invalid schema version")
+
+// decodePayload decodes a payload encoded with the schema the message headers refer to
+// into structured data, and adds the schema subject and version to the metadata.
+// Payloads of messages without schema headers are returned as raw data.
+func decodePayload(ctx context.Context, header nats.Header, data []byte, metadata opencdc.Metadata) (opencdc.Data, error) {
+	subject := common.HeaderValue(header, common.HeaderSchemaSubject)
+	if subject == "" {
+		return opencdc.RawData(data), nil
+	}
+
+	version, err := strconv.Atoi(common.HeaderValue(header, common.HeaderSchemaVersion))
+	if err != nil || version <= 0 {
+		return nil, fmt.Errorf("%w %q of schema %s", ErrInvalidSchemaVersion,
+			common.HeaderValue(header, common.HeaderSchemaVersion), subject)
+	}
+
+	sch, err := schema.Get(ctx, subject, version)
+	if err != nil {
+		return nil, fmt.Errorf("get schema %s:%d: %w", subject, version, err)
+	}
+
+	var structured opencdc.StructuredData
+	if err := sch.Unmarshal(data, &structured); err != nil {
+		return nil, fmt.Errorf("decode with schema %s:%d: %w", subject, version, err)
+	}
+
+	metadata.SetPayloadSchemaSubject(subject)
+	metadata.SetPayloadSchemaVersion(version)
+
+	return structured, nil
+}
+
+// schemaInferrer decodes JSON object payloads into structured data and registers the
+// schemas inferred from them. A schema is registered again whenever the inferred
+// shape of the payloads changes.
+type schemaInferrer struct {
+	// subject is the schema subject inferred schemas are registered under. If empty,
+	// it's the subject the message was published to followed by ".payload".
+	subject string
+	// schemas are the last registered schemas, by schema subject.
+	schemas map[string]inferredSchema
+	logger  *zerolog.Logger
+}
+
+// inferredSchema is a registered schema and its textual representation.
+type inferredSchema struct {
+	text   string
+	schema schema.Schema
+}
+
+func newSchemaInferrer(subject string, logger *zerolog.Logger) *schemaInferrer {
+	return &schemaInferrer{
+		subject: subject,
+		schemas: make(map[string]inferredSchema),
+		logger:  logger,
+	}
+}
+
+// infer decodes a payload that's a JSON object into structured data and adds the subject
+// and version of its schema to the metadata. Other payloads, and JSON objects no Avro
+// schema can be extracted from (e.g. with keys that aren't valid Avro names), are
+// returned as they are.
+func (s *schemaInferrer) infer(
+	ctx context.Context,
+	msgSubject string,
+	payload opencdc.Data,
+	metadata opencdc.Metadata,
+) (opencdc.Data, error) {
+	raw, ok := payload.(opencdc.RawData)
+	if !ok {
+		return payload, nil
+	}
+
+	data, ok := decodeJSONObject(raw)
+	if !ok {
+		return payload, nil
+	}
+
+	subject := s.subject
+	if subject == "" {
+		subject = msgSubject + ".payload"
+	}
+
+	srd, err := schema.KnownSerdeFactories[schema.TypeAvro].SerdeForType(data)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("schemaSubject", subject).
+			Msg("can't infer the payload schema, emitting the payload as raw data")
+
+		return payload, nil
+	}
+
+	last, ok := s.schemas[subject]
+	if text := srd.String(); !ok || last.text != text {
+		sch, err := schema.Create(ctx, schema.TypeAvro, subject, []byte(text))
+		if err != nil {
+			return nil, fmt.Errorf("register payload schema %s: %w", subject, err)
+		}
+
+		last = inferredSchema{text: text, schema: sch}
+		s.schemas[subject] = last
+	}
+
+	metadata.SetPayloadSchemaSubject(last.schema.Subject)
+	metadata.SetPayloadSchemaVersion(last.schema.Version)
+
+	return data, nil
+}
+
+// decodeJSONObject decodes data that's a single JSON object into structured data.
+// Integer numbers are decoded as int64, other numbers as float64.
+func decodeJSONObject(data []byte) (opencdc.StructuredData, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var object map[string]any
+	if err := dec.Decode(&object); err != nil || object == nil {
+		return nil, false
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		// there's more than one JSON value
+		return nil, false
+	}
+
+	for key, value := range object {
+		object[key] = jsonNumbers(value)
+	}
+
+	return opencdc.StructuredData(object), true
+}
+
+// jsonNumbers replaces the JSON numbers in the decoded value with int64 or float64 numbers.
+func jsonNumbers(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+
+		f, _ := value.Float64()
+
+		return f
+	case map[string]any:
+		for k, v := range value {
+			value[k] = jsonNumbers(v)
+		}
+
+		return value
+	case []any:
+		for i, v := range value {
+			value[i] = jsonNumbers(v)
+		}
+
+		return value
+	default:
+		return value
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright © 2026 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/matryer/is"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+)
+
+// encodeWithSchema registers the Avro schema under the subject and returns the headers
+// and payload of a message with the data encoded with it, as the destination publishes it.
+func encodeWithSchema(t *testing.T, subject, avroSchema string, data opencdc.StructuredData) (nats.Header, []byte) {
+	t.Helper()
+
+	sch, err := schema.Create(context.Background(), schema.TypeAvro, subject, []byte(avroSchema))
+	if err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+
+	payload, err := sch.Marshal(data)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	return nats.Header{
+		"Content-Type":           {"application/avro"},
+		"Conduit-Schema-Subject": {sch.Subject},
+		"Conduit-Schema-Version": {strconv.Itoa(sch.Version)},
+		"Conduit-Schema-Id":      {strconv.Itoa(sch.ID)},
+	}, payload
+}
+
+func TestDecodePayload_SchemaEvolution(t *testing.T) {
+	is := is.New(t)
+
+	const subject = "evolution.users.payload"
+
+	// v1 has an id and a name, v2 adds an email, v3 removes the name
+	v1Header, v1Data := encodeWithSchema(t, subject,
+		`{"type":"record","name":"user","fields":[{"name":"id","type":"long"},{"name":"name","type":"string"}]}`,
+		opencdc.StructuredData{"id": int64(1), "name": "ada"})
+	v2Header, v2Data := encodeWithSchema(t, subject,
+		`{"type":"record","name":"user","fields":[{"name":"id","type":"long"},{"name":"name","type":"string"},`+
+			`{"name":"email","type":"string","default":""}]}`,
+		opencdc.StructuredData{"id": int64(2), "name": "grace", "email": "grace@example.com"})
+	v3Header, v3Data := encodeWithSchema(t, subject,
+		`{"type":"record","name":"user","fields":[{"name":"id","type":"long"},{"name":"email","type":"string","default":""}]}`,
+		opencdc.StructuredData{"id": int64(3), "email": "linus@example.com"})
+
+	tests := []struct {
+		name        string
+		header      nats.Header
+		data        []byte
+		want        opencdc.StructuredData
+		wantVersion int
+	}{
+		{
+			name:        "added field",
+			header:      v2Header,
+			data:        v2Data,
+			want:        opencdc.StructuredData{"id": int64(2), "name": "grace", "email": "grace@example.com"},
+			wantVersion: 2,
+		},
+		{
+			name:        "removed field",
+			header:      v3Header,
+			data:        v3Data,
+			want:        opencdc.StructuredData{"id": int64(3), "email": "linus@example.com"},
+			wantVersion: 3,
+		},
+		{
+			name:        "message published before the schema changed",
+			header:      v1Header,
+			data:        v1Data,
+			want:        opencdc.StructuredData{"id": int64(1), "name": "ada"},
+			wantVersion: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			metadata := opencdc.Metadata{}
+			got, err := decodePayload(context.Background(), tt.header, tt.data, metadata)
+			is.NoErr(err)
+			is.Equal(got, tt.want)
+
+			gotSubject, err := metadata.GetPayloadSchemaSubject()
+			is.NoErr(err)
+			is.Equal(gotSubject, subject)
+
+			gotVersion, err := metadata.GetPayloadSchemaVersion()
+			is.NoErr(err)
+			is.Equal(gotVersion, tt.wantVersion)
+		})
+	}
+
+	// messages without schema headers are kept as they are
+	metadata := opencdc.Metadata{}
+	got, err := decodePayload(context.Background(), nats.Header{}, v1Data, metadata)
+	is.NoErr(err)
+	is.Equal(got, opencdc.RawData(v1Data))
+	is.Equal(metadata, opencdc.Metadata{})
+}
+
+func TestDecodePayload_Errors(t *testing.T) {
+	is := is.New(t)
+
+	header, data := encodeWithSchema(t, "errors.payload",
+		`{"type":"record","name":"user","fields":[{"name":"id","type":"long"}]}`,
+		opencdc.StructuredData{"id": int64(1)})
+
+	header.Set("Conduit-Schema-Version", "x")
+	_, err := decodePayload(context.Background(), header, data, opencdc.Metadata{})
+	is.True(errors.Is(err, ErrInvalidSchemaVersion))
+
+	// the schema isn't registered in the schema service
+	header.Set("Conduit-Schema-Version", "2")
+	_, err = decodePayload(context.Background(), header, data, opencdc.Metadata{})
+	is.True(err != nil)
+}
+
+func TestSchemaInferrer_Infer(t *testing.T) {
+	is := is.New(t)
+
+	logger := zerolog.Nop()
+	inferrer := newSchemaInferrer("", &logger)
+
+	infer := func(payload string) (opencdc.Data, int) {
+		t.Helper()
+
+		metadata := opencdc.Metadata{}
+
+		got, err := inferrer.infer(context.Background(), "inferred.users", opencdc.RawData(payload), metadata)
+		is.NoErr(err)
+
+		subject, err := metadata.GetPayloadSchemaSubject()
+		is.NoErr(err)
+		is.Equal(subject, "inferred.users.payload")
+
+		version, err := metadata.GetPayloadSchemaVersion()
+		is.NoErr(err)
+
+		return got, version
+	}
+
+	got, v1 := infer(`{"id":1,"name":"ada","tags":["a"],"address":{"zip":1234}}`)
+	is.Equal(got, opencdc.StructuredData{
+		"id":      int64(1),
+		"name":    "ada",
+		"tags":    []any{"a"},
+		"address": map[string]any{"zip": int64(1234)},
+	})
+
+	// the same shape reuses the schema
+	_, version := infer(`{"id":2,"name":"grace","tags":[],"address":{"zip":5678}}`)
+	is.Equal(version, v1)
+
+	// an added field registers a new version
+	got, v2 := infer(`{"id":3,"name":"linus","tags":[],"address":{"zip":1},"score":9.5}`)
+	is.True(v2 > v1)
+	is.Equal(got.(opencdc.StructuredData)["score"], 9.5)
+
+	// a removed field registers a new version
+	_, v3 := infer(`{"id":4,"tags":[],"address":{"zip":1},"score":1.5}`)
+	is.True(v3 > v2)
+
+	// the inferred schema encodes the decoded payload
+	sch, err := schema.Get(context.Background(), "inferred.users.payload", v3)
+	is.NoErr(err)
+
+	payload := opencdc.StructuredData{"id": int64(4), "tags": []any{"b"}, "address": map[string]any{"zip": int64(1)}, "score": 1.5}
+	encoded, err := sch.Marshal(payload)
+	is.NoErr(err)
+
+	var decoded opencdc.StructuredData
+	is.NoErr(sch.Unmarshal(encoded, &decoded))
+	is.Equal(decoded, payload)
+}
+
+func TestSchemaInferrer_Infer_Skipped(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload opencdc.Data
+	}{
+		{name: "JSON array", payload: opencdc.RawData(`[{"id":1}]`)},
+		{name: "JSON string", payload: opencdc.RawData(`"text"`)},
+		{name: "JSON null", payload: opencdc.RawData(`null`)},
+		{name: "multiple JSON objects", payload: opencdc.RawData(`{"id":1} {"id":2}`)},
+		{name: "not JSON", payload: opencdc.RawData("sample")},
+		{name: "structured data", payload: opencdc.StructuredData{"id": int64(1)}},
+		{name: "key with a dash", payload: opencdc.RawData(`{"order-id":1}`)},
+		{name: "key starting with a digit", payload: opencdc.RawData(`{"1abc":"x"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			logger := zerolog.Nop()
+			metadata := opencdc.Metadata{}
+			got, err := newSchemaInferrer("skipped", &logger).infer(context.Background(), "foo", tt.payload, metadata)
+			is.NoErr(err)
+			is.Equal(got, tt.payload)
+			is.Equal(metadata, opencdc.Metadata{})
+		})
+	}
+}
+
+func TestSchemaInferrer_Infer_Subject(t *testing.T) {
+	is := is.New(t)
+
+	logger := zerolog.Nop()
+	metadata := opencdc.Metadata{}
+	_, err := newSchemaInferrer("configured.payload", &logger).infer(context.Background(), "foo",
+		opencdc.RawData(`{"id":1}`), metadata)
+	is.NoErr(err)
+
+	subject, err := metadata.GetPayloadSchemaSubject()
+	is.NoErr(err)
+	is.Equal(subject, "configured.payload")
+}
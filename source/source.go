@@ -17,19 +17,18 @@ package source
 import (
 	"context"
 	"fmt"
-	"strings"
 
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/common"
 	"github.com/conduitio-labs/conduit-connector-nats-pubsub/source/pubsub"
 	"github.com/conduitio/conduit-commons/config"
 	"github.com/conduitio/conduit-commons/opencdc"
 	sdk "github.com/conduitio/conduit-connector-sdk"
-	"github.com/nats-io/nats.go"
 )
 
 // Iterator defines an iterator interface.
 type Iterator interface {
-	HasNext() bool
 	Next(ctx context.Context) (opencdc.Record, error)
+	NextN(ctx context.Context, n int) ([]opencdc.Record, error)
 	Stop() error
 }
 
@@ -37,9 +36,9 @@ type Iterator interface {
 type Source struct {
 	sdk.UnimplementedSource
 
-	config   Config
-	iterator Iterator
-	errC     chan error
+	config      Config
+	iterator    Iterator
+	stopMetrics func()
 }
 
 // NewSource creates new instance of the Source.
@@ -59,64 +58,85 @@ func (s *Source) Configure(ctx context.Context, cfg config.Config) error {
 		return err //nolint:wrapcheck // we don't need to wrap the error here
 	}
 
+	if err := s.config.Validate(); err != nil {
+		return fmt.Errorf("validate config: %w", err)
+	}
+
 	connName := s.config.GetConnectionName()
-	sdk.Logger(ctx).Info().Str("connectionName", connName).Msg("configured connection name")
+	sdk.Logger(ctx).Info().
+		Str("connectionName", connName).
+		Strs("urls", s.config.RedactedURLs()).
+		Msg("configured connection name")
 
 	return nil
 }
 
 // Open opens a connection to NATS and initializes iterators.
-func (s *Source) Open(context.Context, opencdc.Position) error {
-	s.errC = make(chan error, 1)
+func (s *Source) Open(ctx context.Context, _ opencdc.Position) error {
+	var err error
 
-	opts, err := s.config.ConnectionOptions()
+	s.stopMetrics, err = s.config.Metrics.ServeMetrics()
 	if err != nil {
-		return fmt.Errorf("get connection options: %w", err)
+		return fmt.Errorf("serve metrics: %w", err)
 	}
 
-	conn, err := nats.Connect(strings.Join(s.config.URLs, ","), opts...)
+	conn, err := s.config.Connect(ctx)
 	if err != nil {
 		return fmt.Errorf("connect to NATS: %w", err)
 	}
 
-	// register an error handler for async errors,
-	// the Source listens to them within the Read method and propagates the error if it occurs.
-	conn.SetErrorHandler(func(_ *nats.Conn, _ *nats.Subscription, err error) {
-		s.errC <- err
-	})
-
 	s.iterator, err = pubsub.NewIterator(pubsub.IteratorParams{
-		Conn:       conn,
-		BufferSize: s.config.BufferSize,
-		Subject:    s.config.Subject,
+		Conn:                 conn,
+		BufferSize:           s.config.BufferSize,
+		Subject:              s.config.Subject,
+		ChunkTimeout:         s.config.Chunks.Timeout,
+		ChunkMaxBytes:        s.config.Chunks.MaxBytes,
+		KeyProvider:          s.config.Encryption.NewKeyProvider(),
+		CloudEvents:          s.config.CloudEvents,
+		InferSchema:          s.config.Schema.Infer,
+		SchemaSubject:        s.config.Schema.Subject,
+		Logger:               sdk.Logger(ctx),
+		MaxDecompressedBytes: s.config.Decompression.MaxBytes,
 	})
 	if err != nil {
+		// Teardown doesn't close the connection without an iterator
+		conn.Close()
+
 		return fmt.Errorf("init pubsub iterator: %w", err)
 	}
 
+	if !conn.IsConnected() {
+		sdk.Logger(ctx).Warn().Msg("no NATS server reachable yet, connecting in the background")
+
+		return nil
+	}
+
+	sdk.Logger(ctx).Info().Str("url", common.RedactURL(conn.ConnectedUrl())).Msg("connected to NATS")
+
 	return nil
 }
 
 // Read fetches a record from an iterator.
-// If there's no record will return sdk.ErrBackoffRetry.
-// If the Source's errC is not empty will return the underlying error.
+// It blocks until there's a record, an async error occurs or the context is done.
 func (s *Source) Read(ctx context.Context) (opencdc.Record, error) {
-	select {
-	case err := <-s.errC:
-		return opencdc.Record{}, fmt.Errorf("got an async error: %w", err)
-
-	default:
-		if !s.iterator.HasNext() {
-			return opencdc.Record{}, sdk.ErrBackoffRetry
-		}
+	record, err := s.iterator.Next(ctx)
+	if err != nil {
+		return opencdc.Record{}, fmt.Errorf("read next record: %w", err)
+	}
 
-		record, err := s.iterator.Next(ctx)
-		if err != nil {
-			return opencdc.Record{}, fmt.Errorf("read next record: %w", err)
-		}
+	return record, nil
+}
 
-		return record, nil
+// ReadN fetches up to n records from an iterator.
+// It blocks until there's at least one record, an async error occurs or the context is done,
+// and returns all the records that are already buffered, but no more than n.
+func (s *Source) ReadN(ctx context.Context, n int) ([]opencdc.Record, error) {
+	records, err := s.iterator.NextN(ctx, n)
+	if err != nil {
+		return nil, fmt.Errorf("read next records: %w", err)
 	}
+
+	return records, nil
 }
 
 // Teardown closes connections, stops iterator.
@@ -127,5 +147,9 @@ func (s *Source) Teardown(context.Context) error {
 		}
 	}
 
+	if s.stopMetrics != nil {
+		s.stopMetrics()
+	}
+
 	return nil
 }
@@ -15,13 +15,16 @@
 package source
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/destination"
 	"github.com/conduitio-labs/conduit-connector-nats-pubsub/test"
 	"github.com/conduitio/conduit-commons/opencdc"
 	sdk "github.com/conduitio/conduit-connector-sdk"
@@ -112,6 +115,67 @@ func TestSource_OpenWithToken(t *testing.T) {
 	}
 }
 
+func TestSource_OpenWithCredentialFields(t *testing.T) {
+	t.Setenv("TEST_SOURCE_PASSWORD", "supersecret")
+
+	tests := []struct {
+		name string
+		cfg  map[string]string
+	}{
+		{
+			name: "username and password",
+			cfg: map[string]string{
+				ConfigUrls:     test.TestURLPasswordServer,
+				ConfigUsername: "admin",
+				ConfigPassword: "supersecret",
+			},
+		},
+		{
+			name: "password from environment",
+			cfg: map[string]string{
+				ConfigUrls:     test.TestURLPasswordServer,
+				ConfigUsername: "admin",
+				ConfigPassword: "env:TEST_SOURCE_PASSWORD",
+			},
+		},
+		{
+			name: "token",
+			cfg: map[string]string{
+				ConfigUrls:  test.TestURLTokenServer,
+				ConfigToken: "s3cr3t",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg[ConfigSubject] = "foo"
+
+			source := NewSource()
+			err := source.Configure(context.Background(), tt.cfg)
+			if err != nil {
+				t.Fatalf("configure source: %v", err)
+
+				return
+			}
+
+			err = source.Open(context.Background(), opencdc.Position(nil))
+			if err != nil {
+				t.Fatalf("open source: %v", err)
+
+				return
+			}
+
+			err = source.Teardown(context.Background())
+			if err != nil {
+				t.Fatalf("teardown source: %v", err)
+
+				return
+			}
+		})
+	}
+}
+
 func TestSource_OpenWithNKey(t *testing.T) {
 	source := NewSource()
 	err := source.Configure(context.Background(), map[string]string{
@@ -141,6 +205,45 @@ func TestSource_OpenWithNKey(t *testing.T) {
 	}
 }
 
+func TestSource_OpenWithInlineNKeySeed(t *testing.T) {
+	seed, err := os.ReadFile("../test/fixtures/test_nkey_seed.txt")
+	if err != nil {
+		t.Fatalf("read seed: %v", err)
+
+		return
+	}
+
+	t.Setenv("TEST_SOURCE_NKEY_SEED", string(seed))
+
+	for _, value := range []string{string(seed), "env:TEST_SOURCE_NKEY_SEED"} {
+		source := NewSource()
+		err := source.Configure(context.Background(), map[string]string{
+			ConfigUrls:     test.TestURLWithNKey,
+			ConfigSubject:  "foo",
+			ConfigNkeySeed: value,
+		})
+		if err != nil {
+			t.Fatalf("configure source: %v", err)
+
+			return
+		}
+
+		err = source.Open(context.Background(), opencdc.Position(nil))
+		if err != nil {
+			t.Fatalf("open source: %v", err)
+
+			return
+		}
+
+		err = source.Teardown(context.Background())
+		if err != nil {
+			t.Fatalf("teardown source: %v", err)
+
+			return
+		}
+	}
+}
+
 func TestSource_ReadPubSubSuccessOneMessage(t *testing.T) {
 	subject := "foo_one"
 
@@ -177,19 +280,87 @@ func TestSource_ReadPubSubSuccessOneMessage(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	var record opencdc.Record
-	for {
-		record, err = source.Read(ctx)
-		if err != nil {
-			if errors.Is(err, sdk.ErrBackoffRetry) {
-				continue
-			}
-			t.Fatalf("read message: %v", err)
+	record, err := source.Read(ctx)
+	if err != nil {
+		t.Fatalf("read message: %v", err)
 
-			return
+		return
+	}
+
+	if !reflect.DeepEqual(record.Payload.After.Bytes(), []byte(`{"level": "info"}`)) {
+		t.Fatalf("Source.Read = %v, want %v", record.Payload.After.Bytes(), []byte(`{"level": "info"}`))
+
+		return
+	}
+}
+
+func TestSource_ReadWebSocket(t *testing.T) {
+	subject := "foo_websocket"
+
+	source, err := createTestPubSub(map[string]string{
+		ConfigUrls:               test.TestURLWebSocket,
+		ConfigSubject:            subject,
+		ConfigWebsocketProxyPath: "/nats",
+	})
+	if err != nil {
+		t.Fatalf("create test pubsub: %v", err)
+
+		return
+	}
+
+	t.Cleanup(func() {
+		if err := source.Teardown(context.Background()); err != nil {
+			t.Fatalf("teardown source: %v", err)
 		}
+	})
+
+	// publish over WebSocket as well, through the destination
+	dest := destination.NewDestination()
+
+	err = dest.Configure(context.Background(), map[string]string{
+		destination.ConfigUrls:                 test.TestURLWebSocket,
+		destination.ConfigSubject:              subject,
+		destination.ConfigWebsocketCompression: "true",
+	})
+	if err != nil {
+		t.Fatalf("configure destination: %v", err)
 
-		break
+		return
+	}
+
+	err = dest.Open(context.Background())
+	if err != nil {
+		t.Fatalf("open destination: %v", err)
+
+		return
+	}
+
+	t.Cleanup(func() {
+		if err := dest.Teardown(context.Background()); err != nil {
+			t.Fatalf("teardown destination: %v", err)
+		}
+	})
+
+	_, err = dest.Write(context.Background(), []opencdc.Record{
+		{
+			Operation: opencdc.OperationCreate,
+			Payload:   opencdc.Change{After: opencdc.RawData(`{"level": "info"}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("write record: %v", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	record, err := source.Read(ctx)
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+
+		return
 	}
 
 	if !reflect.DeepEqual(record.Payload.After.Bytes(), []byte(`{"level": "info"}`)) {
@@ -239,11 +410,6 @@ func TestSource_ReadPubSubSuccessManyMessage(t *testing.T) {
 
 		record, err := source.Read(ctx)
 		if err != nil {
-			if errors.Is(err, sdk.ErrBackoffRetry) {
-				i--
-
-				continue
-			}
 			t.Fatalf("read message: %v", err)
 
 			return
@@ -259,8 +425,8 @@ func TestSource_ReadPubSubSuccessManyMessage(t *testing.T) {
 	}
 }
 
-func TestSource_ReadPubSubSuccessNoMessagesBackoff(t *testing.T) {
-	subject := "no_messages"
+func TestSource_ReadNPubSubSuccessManyMessages(t *testing.T) {
+	subject := "foo_read_n"
 
 	source, err := createTestPubSub(map[string]string{
 		ConfigUrls:    test.TestURL,
@@ -278,18 +444,155 @@ func TestSource_ReadPubSubSuccessNoMessagesBackoff(t *testing.T) {
 		}
 	})
 
-	_, err = source.Read(context.Background())
-	if err == nil {
-		t.Fatal("Source.Read expected backoff retry error, got nil")
+	testConn, err := test.GetTestConnection(test.TestURL)
+	if err != nil {
+		t.Fatalf("get test connection: %v", err)
 
 		return
 	}
 
-	if err != nil && !errors.Is(err, sdk.ErrBackoffRetry) {
+	for i := 0; i < 128; i++ {
+		err = testConn.Publish(subject, []byte(`{"level": "info"}`))
+		if err != nil {
+			t.Fatalf("publish message: %v", err)
+
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	records := make([]opencdc.Record, 0, 128)
+	for len(records) < 128 {
+		batch, err := source.ReadN(ctx, 32)
+		if err != nil {
+			t.Fatalf("read messages: %v", err)
+
+			return
+		}
+
+		if len(batch) == 0 || len(batch) > 32 {
+			t.Fatalf("len(batch) = %d, expected between 1 and %d", len(batch), 32)
+
+			return
+		}
+
+		records = append(records, batch...)
+	}
+
+	if len(records) != 128 {
+		t.Fatalf("len(records) = %d, expected = %d", len(records), 128)
+
+		return
+	}
+}
+
+func TestSource_ReadChunkedMessage(t *testing.T) {
+	subject := "foo_chunked"
+
+	source, err := createTestPubSub(map[string]string{
+		ConfigUrls:    test.TestURL,
+		ConfigSubject: subject,
+	})
+	if err != nil {
+		t.Fatalf("create test pubsub: %v", err)
+
+		return
+	}
+
+	t.Cleanup(func() {
+		if err := source.Teardown(context.Background()); err != nil {
+			t.Fatalf("teardown source: %v", err)
+		}
+	})
+
+	dest := destination.NewDestination()
+
+	err = dest.Configure(context.Background(), map[string]string{
+		destination.ConfigUrls:           test.TestURL,
+		destination.ConfigSubject:        subject,
+		destination.ConfigOversizePolicy: "split",
+	})
+	if err != nil {
+		t.Fatalf("configure destination: %v", err)
+
+		return
+	}
+
+	err = dest.Open(context.Background())
+	if err != nil {
+		t.Fatalf("open destination: %v", err)
+
+		return
+	}
+
+	t.Cleanup(func() {
+		if err := dest.Teardown(context.Background()); err != nil {
+			t.Fatalf("teardown destination: %v", err)
+		}
+	})
+
+	// a payload three times as large as the default server's max payload
+	payload := bytes.Repeat([]byte("a"), 3<<20)
+
+	_, err = dest.Write(context.Background(), []opencdc.Record{
+		{
+			Operation: opencdc.OperationCreate,
+			Payload:   opencdc.Change{After: opencdc.RawData(payload)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("write record: %v", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	record, err := source.Read(ctx)
+	if err != nil {
 		t.Fatalf("read message: %v", err)
 
 		return
 	}
+
+	if !bytes.Equal(record.Payload.After.Bytes(), payload) {
+		t.Fatalf("Source.Read payload length = %d, want %d", len(record.Payload.After.Bytes()), len(payload))
+
+		return
+	}
+}
+
+func TestSource_ReadPubSubNoMessagesBlocks(t *testing.T) {
+	subject := "no_messages"
+
+	source, err := createTestPubSub(map[string]string{
+		ConfigUrls:    test.TestURL,
+		ConfigSubject: subject,
+	})
+	if err != nil {
+		t.Fatalf("create test pubsub: %v", err)
+
+		return
+	}
+
+	t.Cleanup(func() {
+		if err := source.Teardown(context.Background()); err != nil {
+			t.Fatalf("teardown source: %v", err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = source.Read(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Source.Read expected context deadline exceeded error, got %v", err)
+
+		return
+	}
 }
 
 func TestSource_ReadPubSubManyMessagesSlowConsumerErr(t *testing.T) {
@@ -319,33 +622,76 @@ func TestSource_ReadPubSubManyMessagesSlowConsumerErr(t *testing.T) {
 		return
 	}
 
-	for i := 0; i < 1_000_000; i++ {
+	// publish more messages than the source buffer can hold before reading anything
+	for i := 0; i < 1_000; i++ {
 		err = testConn.Publish(subject, []byte(`{"level": "info"}`))
 		if err != nil {
 			t.Fatalf("publish test mesage: %v", err)
 
 			return
 		}
+	}
 
-		_, err := source.Read(context.Background())
-		if err != nil {
-			if errors.Is(err, sdk.ErrBackoffRetry) {
-				continue
-			}
+	if err = testConn.Flush(); err != nil {
+		t.Fatalf("flush test connection: %v", err)
 
-			if !errors.Is(errors.Unwrap(err), nats.ErrSlowConsumer) {
-				t.Fatalf("Source.Read expected slow consumer error, got %v", err)
+		return
+	}
 
-				return
-			}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
 
-			return
+	for {
+		_, err := source.Read(ctx)
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, nats.ErrSlowConsumer) {
+			t.Fatalf("Source.Read expected slow consumer error, got %v", err)
+		}
+
+		return
+	}
+}
+
+// BenchmarkSource_Read measures the end-to-end latency of a message
+// published to an idle source until it is returned by the Read method.
+func BenchmarkSource_Read(b *testing.B) {
+	subject := "bench_read"
+
+	source, err := createTestPubSub(map[string]string{
+		ConfigUrls:    test.TestURL,
+		ConfigSubject: subject,
+	})
+	if err != nil {
+		b.Fatalf("create test pubsub: %v", err)
+	}
+
+	b.Cleanup(func() {
+		if err := source.Teardown(context.Background()); err != nil {
+			b.Fatalf("teardown source: %v", err)
 		}
+	})
 
-		continue
+	testConn, err := test.GetTestConnection(test.TestURL)
+	if err != nil {
+		b.Fatalf("get test connection: %v", err)
 	}
+	b.Cleanup(testConn.Close)
 
-	t.Fatalf("Source.Read didn't get the expected slow consumer error")
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := testConn.Publish(subject, []byte(`{"level": "info"}`)); err != nil {
+			b.Fatalf("publish message: %v", err)
+		}
+
+		if _, err := source.Read(ctx); err != nil {
+			b.Fatalf("read message: %v", err)
+		}
+	}
 }
 
 func createTestPubSub(cfg map[string]string) (sdk.Source, error) {
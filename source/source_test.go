@@ -17,9 +17,14 @@ package source
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/conduitio-labs/conduit-connector-nats-pubsub/destination"
 	"github.com/conduitio/conduit-commons/config"
+	"github.com/conduitio/conduit-commons/opencdc"
 	"github.com/matryer/is"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
 )
 
 func TestSource_Configure(t *testing.T) {
@@ -36,6 +41,40 @@ func TestSource_Configure(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "success, subject with wildcards",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:4222",
+				ConfigSubject: "orders.*.>",
+			},
+			wantErr: false,
+		},
+		{
+			name: "fail, subject with whitespace",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:4222",
+				ConfigSubject: "orders created",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid url",
+			cfg: map[string]string{
+				ConfigUrls:    "nats://127.0.0.1:4222,nats://",
+				ConfigSubject: "foo",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, conflicting auth methods",
+			cfg: map[string]string{
+				ConfigUrls:     "nats://127.0.0.1:4222",
+				ConfigSubject:  "foo",
+				ConfigToken:    "s3cr3t",
+				ConfigNkeyPath: "./seed.txt",
+			},
+			wantErr: true,
+		},
 		{
 			name:    "fail, empty config",
 			cfg:     map[string]string{},
@@ -64,3 +103,76 @@ func TestSource_Configure(t *testing.T) {
 		})
 	}
 }
+
+func TestSource_ReadSharedConnectionPermissionViolation(t *testing.T) {
+	is := is.New(t)
+
+	srv, err := server.NewServer(&server.Options{
+		Host:   "127.0.0.1",
+		Port:   server.RANDOM_PORT,
+		NoLog:  true,
+		NoSigs: true,
+		Users: []*server.User{{
+			Username: "app",
+			Password: "secret",
+			Permissions: &server.Permissions{
+				Publish: &server.SubjectPermission{Deny: []string{"denied"}},
+			},
+		}},
+	})
+	is.NoErr(err)
+
+	go srv.Start()
+
+	is.True(srv.ReadyForConnections(5 * time.Second))
+	t.Cleanup(srv.Shutdown)
+
+	cfg := map[string]string{
+		ConfigUrls:             "nats://app:secret@" + srv.Addr().String(),
+		ConfigSubject:          "allowed",
+		ConfigSharedConnection: "true",
+	}
+
+	src := NewSource()
+	is.NoErr(src.Configure(context.Background(), cfg))
+	is.NoErr(src.Open(context.Background(), nil))
+
+	t.Cleanup(func() { _ = src.Teardown(context.Background()) })
+
+	dest := destination.NewDestination()
+	is.NoErr(dest.Configure(context.Background(), map[string]string{
+		destination.ConfigUrls:             cfg[ConfigUrls],
+		destination.ConfigSubject:          "denied",
+		destination.ConfigSharedConnection: "true",
+	}))
+	is.NoErr(dest.Open(context.Background()))
+
+	t.Cleanup(func() { _ = dest.Teardown(context.Background()) })
+
+	is.Equal(srv.NumClients(), 1)
+
+	// the destination's publish is denied, the violation is reported asynchronously
+	_, _ = dest.Write(context.Background(), []opencdc.Record{{
+		Operation: opencdc.OperationCreate,
+		Payload:   opencdc.Change{After: opencdc.RawData("denied")},
+	}})
+
+	time.Sleep(100 * time.Millisecond)
+
+	publisher, err := nats.Connect(cfg[ConfigUrls])
+	is.NoErr(err)
+
+	defer publisher.Close()
+
+	// the source on the same connection keeps reading
+	for range 3 {
+		is.NoErr(publisher.Publish("allowed", []byte("allowed")))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		record, err := src.Read(ctx)
+
+		cancel()
+		is.NoErr(err)
+		is.Equal(record.Payload.After, opencdc.RawData("allowed"))
+	}
+}